@@ -18,6 +18,7 @@ import (
 	"sort"
 
 	"git.wntrmute.dev/kyle/goutils/assert"
+	"github.com/zeebo/blake3"
 	"golang.org/x/crypto/blake2b"
 	"golang.org/x/crypto/blake2s"
 	"golang.org/x/crypto/md4"
@@ -105,6 +106,10 @@ func blakeFunc(bf func(key []byte) (hash.Hash, error)) func() hash.Hash {
 	}
 }
 
+func newBLAKE3() hash.Hash {
+	return blake3.New()
+}
+
 var secureHashes = map[string]func() hash.Hash{
 	"ripemd160":   ripemd160.New,
 	"sha224":      sha256.New224,
@@ -119,6 +124,7 @@ var secureHashes = map[string]func() hash.Hash{
 	"blake2b-256": blakeFunc(blake2b.New256),
 	"blake2b-384": blakeFunc(blake2b.New384),
 	"blake2b-512": blakeFunc(blake2b.New512),
+	"blake3":      newBLAKE3,
 }
 
 func newHash32(f func() hash.Hash32) func() hash.Hash {
@@ -218,6 +224,24 @@ func SumLimitedReader(algo string, r io.Reader, n int64) ([]byte, error) {
 	return SumReader(algo, limit)
 }
 
+// SumLimitedReaderEx is like SumLimitedReader, but also reports
+// whether r was truncated, that is, whether it ran out before n bytes
+// could be read. When truncated is true, the digest only covers the
+// data that was available.
+func SumLimitedReaderEx(algo string, r io.Reader, n int64) (sum []byte, truncated bool, err error) {
+	limit := &io.LimitedReader{
+		R: r,
+		N: n,
+	}
+
+	sum, err = SumReader(algo, limit)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return sum, limit.N > 0, nil
+}
+
 var insecureHashList, secureHashList, hashList []string
 
 func init() {
@@ -155,3 +155,64 @@ func TestSumLimitedReader(t *testing.T) {
 
 	assert.BoolT(t, bytes.Equal(hash, extendedHash), fmt.Sprintf("have hash %x, want %x", extendedHash, hash))
 }
+
+func TestSumLimitedReaderEx(t *testing.T) {
+	data := "hello, world"
+	expected := "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b"
+
+	hash, truncated, err := SumLimitedReaderEx("sha256", bytes.NewBufferString(data+"! more"), int64(len(data)))
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, !truncated, "reader with more data than the limit shouldn't be truncated")
+	assert.BoolT(t, fmt.Sprintf("%x", hash) == expected, fmt.Sprintf("have hash %x, want %s", hash, expected))
+
+	hash, truncated, err = SumLimitedReaderEx("sha256", bytes.NewBufferString(data), int64(len(data)))
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, !truncated, "reader with exactly the limit shouldn't be truncated")
+	assert.BoolT(t, fmt.Sprintf("%x", hash) == expected, fmt.Sprintf("have hash %x, want %s", hash, expected))
+
+	_, truncated, err = SumLimitedReaderEx("sha256", bytes.NewBufferString(data), int64(len(data)+1))
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, truncated, "reader shorter than the limit should be truncated")
+}
+
+func TestBLAKE3(t *testing.T) {
+	algo := "blake3"
+	h, err := New(algo)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, h.IsSecure(), algo+" should be a secure hash")
+
+	var data []byte
+	var expected = "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"
+	sum, err := Sum(algo, data)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, fmt.Sprintf("%x", sum) == expected, fmt.Sprintf("expected hash %s but have %x", expected, sum))
+
+	found := false
+	for _, secure := range SecureHashList() {
+		if secure == algo {
+			found = true
+			break
+		}
+	}
+	assert.BoolT(t, found, algo+" should be in the secure hash list")
+}
+
+func benchmarkSumReader(b *testing.B, algo string) {
+	data := make([]byte, 1<<20)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := SumReader(algo, bytes.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSHA256(b *testing.B) {
+	benchmarkSumReader(b, "sha256")
+}
+
+func BenchmarkBLAKE3(b *testing.B) {
+	benchmarkSumReader(b, "blake3")
+}
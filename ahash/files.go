@@ -0,0 +1,83 @@
+package ahash
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FileResult is the result of hashing a single file with SumFiles.
+// If the file couldn't be hashed, Sum is nil and Err is set.
+type FileResult struct {
+	Path string
+	Sum  []byte
+	Err  error
+}
+
+func sumFile(algo, path string) FileResult {
+	result := FileResult{Path: path}
+
+	file, err := os.Open(path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer file.Close()
+
+	result.Sum, result.Err = SumReader(algo, file)
+	return result
+}
+
+// SumFiles hashes each of the files in paths using the given
+// algorithm, hashing up to concurrency files at once. The results are
+// returned in the same order as paths; a file that couldn't be read
+// has its Err field set rather than failing the whole call. An error
+// is only returned if the algorithm isn't supported.
+func SumFiles(algo string, concurrency int, paths []string) ([]FileResult, error) {
+	if _, err := New(algo); err != nil {
+		return nil, err
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]FileResult, len(paths))
+	work := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range work {
+				results[j] = sumFile(algo, paths[j])
+			}
+		}()
+	}
+
+	for i := range paths {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	return results, nil
+}
+
+// FormatSHA256Sum formats results in the format used by sha256sum
+// (and the other coreutils *sum tools), so that the output can be
+// checked with sha256sum -c. Results with an error are left out.
+func FormatSHA256Sum(results []FileResult) string {
+	var sb strings.Builder
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "%x  %s\n", result.Sum, result.Path)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package ahash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+func TestSumFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 8; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		err := os.WriteFile(path, []byte(fmt.Sprintf("file %d", i)), 0644)
+		assert.NoErrorT(t, err)
+		paths = append(paths, path)
+	}
+	missing := filepath.Join(dir, "missing")
+	paths = append(paths, missing)
+
+	for _, concurrency := range []int{0, 1, 4} {
+		results, err := SumFiles("sha256", concurrency, paths)
+		assert.NoErrorT(t, err)
+		assert.BoolT(t, len(results) == len(paths), fmt.Sprintf("expected %d results, have %d", len(paths), len(results)))
+
+		for i, result := range results[:len(results)-1] {
+			assert.BoolT(t, result.Path == paths[i], fmt.Sprintf("expected result %d to be for %s, have %s", i, paths[i], result.Path))
+			assert.NoErrorT(t, result.Err)
+
+			expected, err := Sum("sha256", []byte(fmt.Sprintf("file %d", i)))
+			assert.NoErrorT(t, err)
+			assert.BoolT(t, string(result.Sum) == string(expected), fmt.Sprintf("bad hash for %s", result.Path))
+		}
+
+		assert.ErrorT(t, results[len(results)-1].Err)
+	}
+
+	_, err := SumFiles("not-a-hash", 1, paths)
+	assert.ErrorT(t, err)
+}
+
+func TestFormatSHA256Sum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello")
+	err := os.WriteFile(path, []byte("hello, world"), 0644)
+	assert.NoErrorT(t, err)
+
+	results, err := SumFiles("sha256", 1, []string{path, path + ".missing"})
+	assert.NoErrorT(t, err)
+
+	expected := "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b  " + path + "\n"
+	out := FormatSHA256Sum(results)
+	assert.BoolT(t, out == expected, fmt.Sprintf("expected %q, have %q", expected, out))
+}
@@ -0,0 +1,106 @@
+package ahash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ParseHashFile reads hashes in the format used by sha256sum (and
+// written by FormatSHA256Sum), returning a FileResult with the path
+// and expected digest for each line. Blank lines are skipped.
+func ParseHashFile(r io.Reader) ([]FileResult, error) {
+	var results []FileResult
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 || len(fields[1]) < 2 {
+			return nil, fmt.Errorf("ahash: line %d: malformed line", lineNo)
+		}
+
+		sum, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("ahash: line %d: %w", lineNo, err)
+		}
+
+		// The second separator character is ' ' for text mode
+		// and '*' for binary mode; they're hashed the same way.
+		results = append(results, FileResult{
+			Path: fields[1][1:],
+			Sum:  sum,
+		})
+	}
+
+	return results, scanner.Err()
+}
+
+// MismatchResult describes a file listed in a hash file that doesn't
+// match its expected SHA-256 digest. If the file couldn't be read,
+// Err is set and Actual is nil.
+type MismatchResult struct {
+	Path     string
+	Expected []byte
+	Actual   []byte
+	Err      error
+}
+
+// VerifyHashFile reads hashFile, which is in the format used by
+// sha256sum, and checks the SHA-256 digest of each file listed in it.
+// Relative paths are taken to be relative to basedir. A
+// MismatchResult is returned for each file that doesn't match or
+// couldn't be read; an error is only returned if hashFile itself
+// couldn't be read or parsed.
+func VerifyHashFile(hashFile string, basedir string) ([]MismatchResult, error) {
+	file, err := os.Open(hashFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	expected, err := ParseHashFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(expected))
+	for _, entry := range expected {
+		path := entry.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(basedir, path)
+		}
+		paths = append(paths, path)
+	}
+
+	results, err := SumFiles("sha256", runtime.NumCPU(), paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []MismatchResult
+	for i, result := range results {
+		if result.Err == nil && bytes.Equal(result.Sum, expected[i].Sum) {
+			continue
+		}
+
+		mismatches = append(mismatches, MismatchResult{
+			Path:     expected[i].Path,
+			Expected: expected[i].Sum,
+			Actual:   result.Sum,
+			Err:      result.Err,
+		})
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,61 @@
+package ahash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+func TestParseHashFile(t *testing.T) {
+	in := "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b  hello\n\n" +
+		"09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b *with space\n"
+	results, err := ParseHashFile(strings.NewReader(in))
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(results) == 2, fmt.Sprintf("expected 2 entries, have %d", len(results)))
+	assert.BoolT(t, results[0].Path == "hello", "expected the path hello, have "+results[0].Path)
+	assert.BoolT(t, results[1].Path == "with space", "expected the path 'with space', have "+results[1].Path)
+
+	_, err = ParseHashFile(strings.NewReader("not-hex  hello\n"))
+	assert.ErrorT(t, err)
+
+	_, err = ParseHashFile(strings.NewReader("09ca7e4e\n"))
+	assert.ErrorT(t, err)
+}
+
+func TestVerifyHashFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"good", "changed"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		assert.NoErrorT(t, err)
+	}
+
+	var lines []string
+	for _, name := range []string{"good", "changed", "missing"} {
+		sum, err := Sum("sha256", []byte(name))
+		assert.NoErrorT(t, err)
+		lines = append(lines, fmt.Sprintf("%x  %s", sum, name))
+	}
+
+	hashFile := filepath.Join(t.TempDir(), "SHA256SUMS")
+	err := os.WriteFile(hashFile, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	assert.NoErrorT(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "changed"), []byte("modified"), 0644)
+	assert.NoErrorT(t, err)
+
+	mismatches, err := VerifyHashFile(hashFile, dir)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(mismatches) == 2, fmt.Sprintf("expected 2 mismatches, have %d", len(mismatches)))
+
+	assert.BoolT(t, mismatches[0].Path == "changed" && mismatches[0].Err == nil && mismatches[0].Actual != nil,
+		fmt.Sprintf("expected a hash mismatch for changed, have %+v", mismatches[0]))
+	assert.BoolT(t, mismatches[1].Path == "missing" && mismatches[1].Err != nil,
+		fmt.Sprintf("expected an error for missing, have %+v", mismatches[1]))
+
+	_, err = VerifyHashFile(filepath.Join(dir, "no-such-file"), dir)
+	assert.ErrorT(t, err)
+}
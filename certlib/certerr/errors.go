@@ -24,8 +24,10 @@ func (t ErrorSourceType) String() string {
 		return "SCT list"
 	case ErrorSourceKeypair:
 		return "TLS keypair"
+	case ErrorSourceClientCertificate:
+		return "client certificate"
 	default:
-		panic(fmt.Sprintf("unknown error source %d", t))
+		return fmt.Sprintf("unknown error source %d", t)
 	}
 }
 
@@ -35,45 +37,197 @@ const (
 	ErrorSourceCSR         ErrorSourceType = 3
 	ErrorSourceSCTList     ErrorSourceType = 4
 	ErrorSourceKeypair     ErrorSourceType = 5
+
+	// ErrorSourceClientCertificate is a certificate presented by a
+	// client, e.g. for TLS client authentication.
+	ErrorSourceClientCertificate ErrorSourceType = 6
 )
 
-// InvalidPEMType is used to indicate that we were expecting one type of PEM
-// file, but saw another.
-type InvalidPEMType struct {
-	have string
-	want []string
+// PEMTypeError is used to indicate that we were expecting one type of PEM
+// file, but saw another. Got is the type that was found, and Wanted lists
+// the acceptable types.
+type PEMTypeError struct {
+	Got    string
+	Wanted []string
 }
 
-func (err *InvalidPEMType) Error() string {
-	if len(err.want) == 1 {
-		return fmt.Sprintf("invalid PEM type: have %s, expected %s", err.have, err.want[0])
+func (err *PEMTypeError) Error() string {
+	if len(err.Wanted) == 1 {
+		return fmt.Sprintf("invalid PEM type: have %s, expected %s", err.Got, err.Wanted[0])
 	} else {
-		return fmt.Sprintf("invalid PEM type: have %s, expected one of %s", err.have, strings.Join(err.want, ", "))
+		return fmt.Sprintf("invalid PEM type: have %s, expected one of %s", err.Got, strings.Join(err.Wanted, ", "))
+	}
+}
+
+// Is reports whether target is a PEMTypeError, so that
+// errors.Is(err, ErrInvalidPEMType) matches any PEM type mismatch.
+func (err *PEMTypeError) Is(target error) bool {
+	_, ok := target.(*PEMTypeError)
+	return ok
+}
+
+// ErrInvalidPEMType is a sentinel matching any PEMTypeError; use
+// errors.As with a *PEMTypeError to get at the details.
+var ErrInvalidPEMType = &PEMTypeError{}
+
+// InvalidPEMType is the old name for PEMTypeError.
+//
+// Deprecated: use PEMTypeError.
+type InvalidPEMType = PEMTypeError
+
+// NewPEMTypeError returns a new PEMTypeError.
+func NewPEMTypeError(got string, wanted ...string) error {
+	return &PEMTypeError{
+		Got:    got,
+		Wanted: wanted,
+	}
+}
+
+// ErrorKind describes what was being done when an error occurred.
+type ErrorKind uint8
+
+const (
+	ErrorKindLoad    ErrorKind = 1
+	ErrorKindParse   ErrorKind = 2
+	ErrorKindDecode  ErrorKind = 3
+	ErrorKindVerify  ErrorKind = 4
+	ErrorKindNetwork ErrorKind = 5
+)
+
+// Error is the error returned by the helpers below (e.g.
+// ParsingError); it records what kind of error occurred and what was
+// being processed, so callers can use errors.As to inspect it.
+type Error struct {
+	Source ErrorSourceType
+	Kind   ErrorKind
+	Err    error
+}
+
+func (err *Error) Error() string {
+	switch err.Kind {
+	case ErrorKindLoad:
+		return fmt.Sprintf("failed to load %s from disk: %v", err.Source, err.Err)
+	case ErrorKindParse:
+		return fmt.Sprintf("failed to parse %s: %v", err.Source, err.Err)
+	case ErrorKindDecode:
+		return fmt.Sprintf("failed to decode %s: %v", err.Source, err.Err)
+	case ErrorKindVerify:
+		return fmt.Sprintf("failed to verify %s: %v", err.Source, err.Err)
+	case ErrorKindNetwork:
+		return fmt.Sprintf("failed to fetch %s: %v", err.Source, err.Err)
+	default:
+		return fmt.Sprintf("%s error: %v", err.Source, err.Err)
+	}
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// Equal reports whether err and other describe the same condition:
+// they must have the same kind and source, and their underlying
+// errors must have the same message.
+func (err *Error) Equal(other *Error) bool {
+	if err == nil || other == nil {
+		return err == other
+	}
+
+	if err.Kind != other.Kind || err.Source != other.Source {
+		return false
+	}
+
+	if err.Err == nil || other.Err == nil {
+		return err.Err == other.Err
+	}
+
+	return err.Err.Error() == other.Err.Error()
+}
+
+// ErrorsEqual reports whether a and b both wrap an *Error and those
+// errors are Equal.
+func ErrorsEqual(a, b error) bool {
+	var aerr, berr *Error
+	if !errors.As(a, &aerr) || !errors.As(b, &berr) {
+		return false
+	}
+
+	return aerr.Equal(berr)
+}
+
+// FormatError returns a short, single-line description of err for
+// command-line output, such as "certificate parse error: trailing
+// data at end of certificate". Errors that didn't come from this
+// package are returned as is.
+func FormatError(err error) string {
+	var cerr *Error
+	if !errors.As(err, &cerr) {
+		return err.Error()
+	}
+
+	kind := kindName(cerr.Kind)
+	if kind == "" {
+		return fmt.Sprintf("%s error: %v", cerr.Source, cerr.Err)
+	}
+
+	return fmt.Sprintf("%s %s error: %v", cerr.Source, kind, cerr.Err)
+}
+
+// kindName returns a short name for kind, or an empty string if the
+// kind is unknown.
+func kindName(kind ErrorKind) string {
+	switch kind {
+	case ErrorKindLoad:
+		return "load"
+	case ErrorKindParse:
+		return "parse"
+	case ErrorKindDecode:
+		return "decode"
+	case ErrorKindVerify:
+		return "verification"
+	case ErrorKindNetwork:
+		return "network"
+	default:
+		return ""
 	}
 }
 
-// ErrInvalidPEMType returns a new InvalidPEMType error.
-func ErrInvalidPEMType(have string, want ...string) error {
-	return &InvalidPEMType{
-		have: have,
-		want: want,
+// String returns a short, human-readable name for kind, such as
+// "parse".
+func (kind ErrorKind) String() string {
+	if name := kindName(kind); name != "" {
+		return name
 	}
+
+	return fmt.Sprintf("unknown error kind %d", uint8(kind))
+}
+
+func newError(t ErrorSourceType, kind ErrorKind, err error) error {
+	count(t, kind)
+	return &Error{Source: t, Kind: kind, Err: err}
 }
 
 func LoadingError(t ErrorSourceType, err error) error {
-	return fmt.Errorf("failed to load %s from disk: %w", t, err)
+	return newError(t, ErrorKindLoad, err)
 }
 
 func ParsingError(t ErrorSourceType, err error) error {
-	return fmt.Errorf("failed to parse %s: %w", t, err)
+	return newError(t, ErrorKindParse, err)
 }
 
 func DecodeError(t ErrorSourceType, err error) error {
-	return fmt.Errorf("failed to decode %s: %w", t, err)
+	return newError(t, ErrorKindDecode, err)
 }
 
 func VerifyError(t ErrorSourceType, err error) error {
-	return fmt.Errorf("failed to verify %s: %w", t, err)
+	return newError(t, ErrorKindVerify, err)
+}
+
+func NetworkError(t ErrorSourceType, err error) error {
+	return newError(t, ErrorKindNetwork, err)
 }
 
 var ErrEncryptedPrivateKey = errors.New("private key is encrypted")
+
+// ErrCertificateChainIncomplete indicates that a chain to a trusted
+// root couldn't be built, usually because intermediates are missing.
+var ErrCertificateChainIncomplete = errors.New("incomplete certificate chain")
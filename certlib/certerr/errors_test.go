@@ -0,0 +1,110 @@
+package certerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestInvalidPEMType(t *testing.T) {
+	err := ParsingError(ErrorSourceCSR, NewPEMTypeError("CERTIFICATE", "CERTIFICATE REQUEST"))
+	if !errors.Is(err, ErrInvalidPEMType) {
+		t.Fatalf("certerr: expected %v to match ErrInvalidPEMType", err)
+	}
+
+	var pemErr *PEMTypeError
+	if !errors.As(err, &pemErr) {
+		t.Fatalf("certerr: expected %v to be a PEMTypeError", err)
+	}
+
+	if pemErr.Got != "CERTIFICATE" {
+		t.Fatalf("certerr: expected PEM type CERTIFICATE, have %s", pemErr.Got)
+	}
+
+	if len(pemErr.Wanted) != 1 || pemErr.Wanted[0] != "CERTIFICATE REQUEST" {
+		t.Fatalf("certerr: expected wanted PEM type CERTIFICATE REQUEST, have %v", pemErr.Wanted)
+	}
+
+	var oldErr *InvalidPEMType
+	if !errors.As(err, &oldErr) {
+		t.Fatalf("certerr: expected %v to match the deprecated InvalidPEMType", err)
+	}
+
+	if errors.Is(ParsingError(ErrorSourceCSR, ErrEmptyCertificate), ErrInvalidPEMType) {
+		t.Fatal("certerr: ErrEmptyCertificate shouldn't match ErrInvalidPEMType")
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	err := errors.New("trailing data at end of certificate")
+	if s := FormatError(ParsingError(ErrorSourceCertificate, err)); s != "certificate parse error: trailing data at end of certificate" {
+		t.Fatalf("certerr: unexpected formatted error '%s'", s)
+	}
+
+	wrapped := fmt.Errorf("loading bundle: %w", VerifyError(ErrorSourceCSR, err))
+	if s := FormatError(wrapped); s != "CSR verification error: trailing data at end of certificate" {
+		t.Fatalf("certerr: unexpected formatted error '%s'", s)
+	}
+
+	if s := FormatError(err); s != err.Error() {
+		t.Fatalf("certerr: expected other errors to be unchanged, have '%s'", s)
+	}
+}
+
+func TestErrorsEqual(t *testing.T) {
+	err := ParsingError(ErrorSourceCertificate, errors.New("trailing data"))
+	if !ErrorsEqual(err, ParsingError(ErrorSourceCertificate, errors.New("trailing data"))) {
+		t.Fatal("certerr: expected errors with the same kind, source, and cause to be equal")
+	}
+
+	if !ErrorsEqual(fmt.Errorf("loading bundle: %w", err), err) {
+		t.Fatal("certerr: expected a wrapped error to equal the original")
+	}
+
+	tests := []error{
+		DecodeError(ErrorSourceCertificate, errors.New("trailing data")),
+		ParsingError(ErrorSourceCSR, errors.New("trailing data")),
+		ParsingError(ErrorSourceCertificate, errors.New("no data")),
+		ParsingError(ErrorSourceCertificate, nil),
+		errors.New("failed to parse certificate: trailing data"),
+		nil,
+	}
+
+	for _, other := range tests {
+		if ErrorsEqual(err, other) {
+			t.Fatalf("certerr: expected '%v' not to equal '%v'", err, other)
+		}
+	}
+}
+
+func TestStringers(t *testing.T) {
+	if s := ErrorKindParse.String(); s != "parse" {
+		t.Fatalf("certerr: expected ErrorKindParse to be 'parse', have '%s'", s)
+	}
+
+	if s := ErrorSourceCertificate.String(); s != "certificate" {
+		t.Fatalf("certerr: expected ErrorSourceCertificate to be 'certificate', have '%s'", s)
+	}
+
+	if s := fmt.Sprintf("kind=%s source=%s", ErrorKind(42), ErrorSourceType(42)); s != "kind=unknown error kind 42 source=unknown error source 42" {
+		t.Fatalf("certerr: unexpected names for unknown values '%s'", s)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	cause := fmt.Errorf("reading PEM block: %w", io.ErrUnexpectedEOF)
+	err := fmt.Errorf("loading bundle: %w", DecodeError(ErrorSourceCertificate, cause))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("certerr: expected %v to match io.ErrUnexpectedEOF", err)
+	}
+
+	var cerr *Error
+	if !errors.As(err, &cerr) || cerr.Unwrap() != cause {
+		t.Fatalf("certerr: expected %v to unwrap to its cause", err)
+	}
+
+	if errors.Is(DecodeError(ErrorSourceCertificate, nil), io.ErrUnexpectedEOF) {
+		t.Fatal("certerr: an error without a cause shouldn't match io.ErrUnexpectedEOF")
+	}
+}
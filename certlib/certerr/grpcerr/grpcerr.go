@@ -0,0 +1,49 @@
+// Package grpcerr maps certerr errors to gRPC status codes. It's kept
+// apart from certerr so that only programs that use it depend on gRPC.
+package grpcerr
+
+import (
+	"errors"
+	"io/fs"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"google.golang.org/grpc/codes"
+)
+
+// Code returns the gRPC status code that best describes err, following
+// the same rules as certerr.HTTPStatus: a nil error is OK, a
+// certificate that couldn't be found is NotFound, one that couldn't be
+// parsed or decoded is InvalidArgument, a client certificate that
+// failed verification is Unauthenticated, and any other verification
+// failure is FailedPrecondition. A failure to fetch a certificate from
+// elsewhere is Unavailable. Anything else is Internal, or Unknown for
+// errors that didn't come from certerr.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	var cerr *certerr.Error
+	if !errors.As(err, &cerr) {
+		return codes.Unknown
+	}
+
+	switch cerr.Kind {
+	case certerr.ErrorKindLoad:
+		if errors.Is(cerr.Err, fs.ErrNotExist) {
+			return codes.NotFound
+		}
+		return codes.Internal
+	case certerr.ErrorKindParse, certerr.ErrorKindDecode:
+		return codes.InvalidArgument
+	case certerr.ErrorKindVerify:
+		if cerr.Source == certerr.ErrorSourceClientCertificate {
+			return codes.Unauthenticated
+		}
+		return codes.FailedPrecondition
+	case certerr.ErrorKindNetwork:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,37 @@
+package grpcerr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"google.golang.org/grpc/codes"
+)
+
+func TestCode(t *testing.T) {
+	err := errors.New("test error")
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{nil, codes.OK},
+		{certerr.LoadingError(certerr.ErrorSourceCertificate, os.ErrNotExist), codes.NotFound},
+		{certerr.LoadingError(certerr.ErrorSourceCertificate, os.ErrPermission), codes.Internal},
+		{certerr.ParsingError(certerr.ErrorSourceCertificate, err), codes.InvalidArgument},
+		{certerr.DecodeError(certerr.ErrorSourcePrivateKey, err), codes.InvalidArgument},
+		{certerr.VerifyError(certerr.ErrorSourceCertificate, err), codes.FailedPrecondition},
+		{certerr.VerifyError(certerr.ErrorSourceClientCertificate, err), codes.Unauthenticated},
+		{certerr.NetworkError(certerr.ErrorSourceCertificate, err), codes.Unavailable},
+		{&certerr.Error{Source: certerr.ErrorSourceCertificate, Kind: 0, Err: err}, codes.Internal},
+		{fmt.Errorf("wrapped: %w", certerr.ParsingError(certerr.ErrorSourceCSR, err)), codes.InvalidArgument},
+		{err, codes.Unknown},
+	}
+
+	for _, test := range tests {
+		if code := Code(test.err); code != test.code {
+			t.Fatalf("grpcerr: expected code %s for '%v', have %s", test.code, test.err, code)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package certerr
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+)
+
+// HTTPStatus returns the HTTP status code that best describes err: a
+// nil error is a 200, a certificate that couldn't be found is a 404,
+// one that couldn't be parsed or decoded is a 400, a client
+// certificate that failed verification is a 401, and any other
+// verification failure is a 422. A failure to fetch a certificate
+// from elsewhere is a 502. Anything else, including errors that didn't
+// come from this package, is a 500.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var cerr *Error
+	if !errors.As(err, &cerr) {
+		return http.StatusInternalServerError
+	}
+
+	switch cerr.Kind {
+	case ErrorKindLoad:
+		if errors.Is(cerr.Err, fs.ErrNotExist) {
+			return http.StatusNotFound
+		}
+		return http.StatusInternalServerError
+	case ErrorKindParse, ErrorKindDecode:
+		return http.StatusBadRequest
+	case ErrorKindVerify:
+		if cerr.Source == ErrorSourceClientCertificate {
+			return http.StatusUnauthorized
+		}
+		return http.StatusUnprocessableEntity
+	case ErrorKindNetwork:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// problemDetail is an RFC 7807 problem details object.
+type problemDetail struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// WriteProblemDetail writes err to w as an RFC 7807 problem details
+// response, using HTTPStatus for the status code.
+func WriteProblemDetail(w http.ResponseWriter, err error) {
+	status := HTTPStatus(err)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&problemDetail{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+	})
+}
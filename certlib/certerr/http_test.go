@@ -0,0 +1,60 @@
+package certerr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	err := errors.New("test error")
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{nil, http.StatusOK},
+		{LoadingError(ErrorSourceCertificate, os.ErrNotExist), http.StatusNotFound},
+		{LoadingError(ErrorSourceCertificate, os.ErrPermission), http.StatusInternalServerError},
+		{ParsingError(ErrorSourceCertificate, err), http.StatusBadRequest},
+		{DecodeError(ErrorSourcePrivateKey, err), http.StatusBadRequest},
+		{VerifyError(ErrorSourceCertificate, err), http.StatusUnprocessableEntity},
+		{VerifyError(ErrorSourceClientCertificate, err), http.StatusUnauthorized},
+		{NetworkError(ErrorSourceCertificate, err), http.StatusBadGateway},
+		{&Error{Source: ErrorSourceCertificate, Kind: 0, Err: err}, http.StatusInternalServerError},
+		{fmt.Errorf("wrapped: %w", ParsingError(ErrorSourceCSR, err)), http.StatusBadRequest},
+		{err, http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if status := HTTPStatus(test.err); status != test.status {
+			t.Fatalf("certerr: expected status %d for '%v', have %d", test.status, test.err, status)
+		}
+	}
+}
+
+func TestWriteProblemDetail(t *testing.T) {
+	err := VerifyError(ErrorSourceCertificate, errors.New("test error"))
+	w := httptest.NewRecorder()
+	WriteProblemDetail(w, err)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("certerr: expected status %d, have %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("certerr: expected a problem+json content type, have %s", ct)
+	}
+
+	var problem problemDetail
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+
+	if problem.Status != w.Code || problem.Detail != err.Error() {
+		t.Fatalf("certerr: unexpected problem details %+v", problem)
+	}
+}
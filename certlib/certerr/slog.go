@@ -0,0 +1,38 @@
+package certerr
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+)
+
+// LogValue implements slog.LogValuer, so that an *Error passed to a
+// slog.Logger is logged as a group with its kind, source, and cause.
+func (err *Error) LogValue() slog.Value {
+	kind := kindName(err.Kind)
+	if kind == "" {
+		kind = strconv.Itoa(int(err.Kind))
+	}
+
+	attrs := []slog.Attr{
+		slog.String("kind", kind),
+		slog.String("source", err.Source.String()),
+	}
+
+	if err.Err != nil {
+		attrs = append(attrs, slog.String("cause", err.Err.Error()))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// AsAttrs returns the structured fields of the first *Error in err's
+// chain as slog attributes, or nil if there isn't one.
+func AsAttrs(err error) []slog.Attr {
+	var cerr *Error
+	if !errors.As(err, &cerr) {
+		return nil
+	}
+
+	return cerr.LogValue().Group()
+}
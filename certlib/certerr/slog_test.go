@@ -0,0 +1,45 @@
+package certerr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func checkLogOutput(t *testing.T, out string) {
+	for _, expected := range []string{"err.kind=parse", "err.source=certificate", `err.cause="trailing data"`} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("certerr: expected log output to contain '%s', have '%s'", expected, out)
+		}
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+	logger.Error("failed", "err", ParsingError(ErrorSourceCertificate, errors.New("trailing data")))
+	checkLogOutput(t, buf.String())
+}
+
+func TestAsAttrs(t *testing.T) {
+	err := ParsingError(ErrorSourceCertificate, errors.New("trailing data"))
+	wrapped := fmt.Errorf("loading bundle: %w", fmt.Errorf("reading file: %w", err))
+
+	for _, err := range []error{err, wrapped} {
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewTextHandler(buf, nil))
+		logger.LogAttrs(context.Background(), slog.LevelError, "failed", slog.Attr{
+			Key:   "err",
+			Value: slog.GroupValue(AsAttrs(err)...),
+		})
+		checkLogOutput(t, buf.String())
+	}
+
+	if attrs := AsAttrs(errors.New("trailing data")); attrs != nil {
+		t.Fatalf("certerr: expected no attributes for other errors, have %v", attrs)
+	}
+}
@@ -0,0 +1,47 @@
+package certerr
+
+import "sync"
+
+var stats = struct {
+	sync.Mutex
+	counts map[ErrorSourceType]map[ErrorKind]int
+}{
+	counts: map[ErrorSourceType]map[ErrorKind]int{},
+}
+
+func count(t ErrorSourceType, kind ErrorKind) {
+	stats.Lock()
+	defer stats.Unlock()
+
+	if stats.counts[t] == nil {
+		stats.counts[t] = map[ErrorKind]int{}
+	}
+	stats.counts[t][kind]++
+}
+
+// Stats returns the number of errors of each kind that have been
+// constructed for each error source by the error helpers (e.g.
+// ParsingError). The returned map is a copy, and may be modified by
+// the caller.
+func Stats() map[ErrorSourceType]map[ErrorKind]int {
+	stats.Lock()
+	defer stats.Unlock()
+
+	counts := make(map[ErrorSourceType]map[ErrorKind]int, len(stats.counts))
+	for t, kinds := range stats.counts {
+		counts[t] = make(map[ErrorKind]int, len(kinds))
+		for kind, n := range kinds {
+			counts[t][kind] = n
+		}
+	}
+
+	return counts
+}
+
+// ResetStats clears the error counters.
+func ResetStats() {
+	stats.Lock()
+	defer stats.Unlock()
+
+	stats.counts = map[ErrorSourceType]map[ErrorKind]int{}
+}
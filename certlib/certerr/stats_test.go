@@ -0,0 +1,49 @@
+package certerr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	ResetStats()
+
+	err := errors.New("test error")
+	ParsingError(ErrorSourceCertificate, err)
+	ParsingError(ErrorSourceCertificate, err)
+	DecodeError(ErrorSourcePrivateKey, err)
+	LoadingError(ErrorSourceKeypair, err)
+	VerifyError(ErrorSourceCertificate, err)
+
+	stats := Stats()
+	expected := map[ErrorSourceType]map[ErrorKind]int{
+		ErrorSourceCertificate: {ErrorKindParse: 2, ErrorKindVerify: 1},
+		ErrorSourcePrivateKey:  {ErrorKindDecode: 1},
+		ErrorSourceKeypair:     {ErrorKindLoad: 1},
+	}
+
+	for source, kinds := range expected {
+		for kind, n := range kinds {
+			if stats[source][kind] != n {
+				t.Fatalf("certerr: expected %d errors of kind %d for %s, have %d",
+					n, kind, source, stats[source][kind])
+			}
+		}
+	}
+
+	if len(stats[ErrorSourceCSR]) != 0 {
+		t.Fatalf("certerr: expected no CSR errors, have %v", stats[ErrorSourceCSR])
+	}
+
+	// Stats returns a copy, so changing it shouldn't affect the
+	// counters.
+	stats[ErrorSourceCertificate][ErrorKindParse] = 0
+	if Stats()[ErrorSourceCertificate][ErrorKindParse] != 2 {
+		t.Fatal("certerr: modifying the result of Stats changed the counters")
+	}
+
+	ResetStats()
+	if len(Stats()) != 0 {
+		t.Fatal("certerr: expected ResetStats to clear the counters")
+	}
+}
@@ -0,0 +1,33 @@
+package certerr
+
+import (
+	"errors"
+	"net"
+)
+
+// Transient reports whether err is a network error, which is likely to
+// be worth retrying. Any package can classify errors from this one by
+// checking for a Transient method, as lib.IsTransient does, without
+// importing certerr.
+func (err *Error) Transient() bool {
+	return err.Kind == ErrorKindNetwork
+}
+
+// IsTransient reports whether err is likely to be transient, such that
+// the operation that caused it is worth retrying: either an error with
+// a Transient method that returns true, such as a network error from
+// this package, or a net.Error (including a syscall.Errno) that is a
+// timeout or is marked as temporary.
+func IsTransient(err error) bool {
+	var terr interface{ Transient() bool }
+	if errors.As(err, &terr) && terr.Transient() {
+		return true
+	}
+
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		return nerr.Timeout() || nerr.Temporary()
+	}
+
+	return false
+}
@@ -0,0 +1,38 @@
+package certerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestIsTransient(t *testing.T) {
+	err := errors.New("test error")
+	transient := []error{
+		NetworkError(ErrorSourceCertificate, err),
+		&net.DNSError{Err: "timeout", IsTimeout: true},
+		fmt.Errorf("fetching CRL: %w", context.DeadlineExceeded),
+		&net.OpError{Op: "read", Net: "tcp", Err: syscall.ETIMEDOUT},
+	}
+
+	for _, err := range transient {
+		if !IsTransient(err) {
+			t.Fatalf("certerr: expected '%v' to be transient", err)
+		}
+	}
+
+	permanent := []error{
+		err,
+		ParsingError(ErrorSourceCertificate, err),
+		VerifyError(ErrorSourceCertificate, &net.DNSError{Err: "no such host", IsNotFound: true}),
+	}
+
+	for _, err := range permanent {
+		if IsTransient(err) {
+			t.Fatalf("certerr: expected '%v' not to be transient", err)
+		}
+	}
+}
@@ -1,10 +1,17 @@
 package certlib
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 
 	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
 )
@@ -26,7 +33,7 @@ func ReadCertificate(in []byte) (cert *x509.Certificate, rest []byte, err error)
 
 		rest = remaining
 		if p.Type != "CERTIFICATE" {
-			err = certerr.ErrInvalidPEMType(p.Type, "CERTIFICATE")
+			err = certerr.NewPEMTypeError(p.Type, "CERTIFICATE")
 			return
 		}
 
@@ -83,3 +90,111 @@ func LoadCertificates(path string) ([]*x509.Certificate, error) {
 
 	return ReadCertificates(in)
 }
+
+// LoadCertificateChain reads a PEM bundle and splits it into the leaf
+// and its intermediates, after ordering them with NormalizeChain. If
+// the bundle contains a single certificate, there are no
+// intermediates.
+func LoadCertificateChain(path string) (leaf *x509.Certificate, intermediates []*x509.Certificate, err error) {
+	chain, err := ParseCertificatesPEMFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(chain) == 0 {
+		return nil, nil, certerr.DecodeError(certerr.ErrorSourceCertificate, errors.New("no certificates found"))
+	}
+
+	chain = NormalizeChain(chain)
+	return chain[0], chain[1:], nil
+}
+
+// ReadCertificateStdin reads all of standard input and parses the
+// PEM-encoded certificates in it, for commands that accept "-" as a
+// file name.
+func ReadCertificateStdin() ([]*x509.Certificate, error) {
+	in, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCertificatesPEM(in)
+}
+
+// LoadPrivateKey tries to read a private key from disk. The key may
+// be either PEM or DER-encoded, and must not be encrypted.
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	in = bytes.TrimSpace(in)
+	if p, _ := pem.Decode(in); p != nil {
+		return ParsePrivateKeyPEM(in)
+	}
+
+	return ParsePrivateKeyDER(in)
+}
+
+// ValidatePrivateKeySize checks that key is at least minRSABits long
+// if it is an RSA key, or minECBits long if it is an ECDSA key. Other
+// key types have a fixed size and aren't checked. A minimum of zero
+// disables the check for that key type.
+func ValidatePrivateKeySize(key crypto.Signer, minRSABits, minECBits int) error {
+	var keyType string
+	var minBits int
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		keyType, minBits = "RSA", minRSABits
+	case *ecdsa.PublicKey:
+		keyType, minBits = "ECDSA", minECBits
+	default:
+		return nil
+	}
+
+	if bits := KeyLength(key.Public()); bits < minBits {
+		return certerr.VerifyError(certerr.ErrorSourcePrivateKey,
+			fmt.Errorf("%s key is %d bits, less than the minimum of %d bits", keyType, bits, minBits))
+	}
+
+	return nil
+}
+
+// LoadPrivateKeyWithSizeCheck loads a private key with LoadPrivateKey,
+// and checks its size with ValidatePrivateKeySize.
+func LoadPrivateKeyWithSizeCheck(path string, minRSABits, minECBits int) (crypto.Signer, error) {
+	key, err := LoadPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ValidatePrivateKeySize(key, minRSABits, minECBits); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// BatchLoadCertificates reads all the certificates in each of the
+// files in paths. Unlike LoadCertificates, it doesn't stop at the
+// first failure: the returned errors have the same length as paths,
+// and a nil error means that the corresponding file was loaded
+// successfully. The certificates from every file that loaded are
+// returned in order.
+func BatchLoadCertificates(paths []string) ([]*x509.Certificate, []error) {
+	var certs []*x509.Certificate
+	errs := make([]error, len(paths))
+
+	for i, path := range paths {
+		fileCerts, err := LoadCertificates(path)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		certs = append(certs, fileCerts...)
+	}
+
+	return certs, errs
+}
@@ -1,8 +1,24 @@
 package certlib
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"git.wntrmute.dev/kyle/goutils/assert"
 )
@@ -137,3 +153,344 @@ func TestReadCertificates(t *testing.T) {
 		assert.BoolT(t, cert != nil, "lib: expected an actual certificate to have been returned")
 	}
 }
+
+func TestBatchLoadCertificates(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.pem")
+	bad := filepath.Join(dir, "bad.pem")
+	missing := filepath.Join(dir, "missing.pem")
+
+	assert.NoErrorT(t, os.WriteFile(good, []byte(testCerts), 0644))
+	assert.NoErrorT(t, os.WriteFile(bad, []byte("-----BEGIN CERTIFICATE-----\nnope\n"), 0644))
+
+	certs, errs := BatchLoadCertificates([]string{good, bad, missing, good})
+	assert.BoolT(t, len(certs) == 6, fmt.Sprintf("lib: expected six certificates, have %d", len(certs)))
+	assert.BoolT(t, len(errs) == 4, fmt.Sprintf("lib: expected four errors, have %d", len(errs)))
+
+	assert.NoErrorT(t, errs[0])
+	assert.ErrorT(t, errs[1], "lib: expected an error loading an invalid PEM file")
+	assert.ErrorT(t, errs[2], "lib: expected an error loading a missing file")
+	assert.NoErrorT(t, errs[3])
+}
+
+func TestSelfSignedPool(t *testing.T) {
+	roots, err := ReadCertificates([]byte(testCerts))
+	assert.NoErrorT(t, err)
+
+	pool, err := SelfSignedPool(roots)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, pool != nil, "lib: expected a certificate pool")
+
+	// The issuer has the same name but a different key.
+	tmpl := &x509.Certificate{Subject: pkix.Name{CommonName: "not self-signed"}}
+	cert, _ := issueTestCert(t, tmpl, nil, tmpl, newTestKey(t))
+
+	pool, err = SelfSignedPool(append(roots, cert))
+	assert.ErrorT(t, err, "lib: expected an error for a certificate that isn't self-signed")
+	assert.BoolT(t, pool != nil, "lib: expected a certificate pool despite the error")
+	assert.BoolT(t, strings.HasPrefix(err.Error(), "failed to verify certificate"),
+		fmt.Sprintf("lib: expected a verification error, have %v", err))
+}
+
+func TestLoadCertificateDER(t *testing.T) {
+	roots, err := ReadCertificates([]byte(testCerts))
+	assert.NoErrorT(t, err)
+
+	path := filepath.Join(t.TempDir(), "root.crt")
+	assert.NoErrorT(t, os.WriteFile(path, roots[0].Raw, 0644))
+
+	cert, err := LoadCertificate(path)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, cert.Equal(roots[0]), "lib: expected the DER certificate to match the original")
+
+	certs, err := LoadCertificates(path)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(certs) == 1, fmt.Sprintf("lib: expected one certificate, have %d", len(certs)))
+}
+
+// newTestKey returns a new P-256 key.
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoErrorT(t, err)
+	return key
+}
+
+// testCA returns a template for a CA certificate for name that
+// expires at notAfter, for use with issueTestCert.
+func testCA(name string, notAfter time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+}
+
+// issueTestCert issues a certificate from tmpl for key, signed by
+// issuerKey under issuer, and returns it with its key. If key is nil,
+// a new key is generated; if issuer is nil, the certificate is
+// self-signed. The issuer only needs a subject, so it may be the
+// template for a certificate that hasn't been issued yet. A missing
+// serial number or validity period is filled in.
+func issueTestCert(t *testing.T, tmpl *x509.Certificate, key *ecdsa.PrivateKey, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	if key == nil {
+		key = newTestKey(t)
+	}
+
+	if tmpl.SerialNumber == nil {
+		tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	}
+
+	if tmpl.NotBefore.IsZero() {
+		tmpl.NotBefore = time.Now().Add(-time.Hour)
+	}
+
+	if tmpl.NotAfter.IsZero() {
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+	}
+
+	if issuer == nil {
+		issuer, issuerKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, &key.PublicKey, issuerKey)
+	assert.NoErrorT(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoErrorT(t, err)
+	return cert, key
+}
+
+func TestLeafExpiryTime(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	root, rootKey := issueTestCert(t, testCA("root", now.Add(3*OneYear)), nil, nil, nil)
+	inter, interKey := issueTestCert(t, testCA("intermediate", now.Add(OneDay)), nil, root, rootKey)
+	leaf, _ := issueTestCert(t, testCA("leaf", now.Add(OneYear)), nil, inter, interKey)
+
+	chain := NormalizeChain([]*x509.Certificate{root, leaf, inter})
+	assert.BoolT(t, len(chain) == 3, fmt.Sprintf("lib: expected three certificates, have %d", len(chain)))
+	assert.BoolT(t, chain[0] == leaf && chain[1] == inter && chain[2] == root,
+		"lib: expected the chain to be ordered leaf, intermediate, root")
+
+	chain = []*x509.Certificate{inter, root, leaf}
+	assert.BoolT(t, LeafExpiryTime(chain).Equal(leaf.NotAfter), "lib: expected the leaf's expiry time")
+	assert.BoolT(t, ExpiryTime(chain).Equal(inter.NotAfter), "lib: expected the chain's expiry time")
+	assert.BoolT(t, LeafExpiryTime(nil).IsZero(), "lib: expected a zero expiry time for an empty chain")
+}
+
+func TestParseCertificatesPEMFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "certs.pem")
+	assert.NoErrorT(t, os.WriteFile(path, []byte(testCerts), 0644))
+
+	certs, err := ParseCertificatesPEMFile(path)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(certs) == 3, fmt.Sprintf("lib: expected three certificates, have %d", len(certs)))
+
+	_, err = ParseCertificatePEMFile(path)
+	assert.ErrorT(t, err, "lib: expected an error parsing multiple certificates as one")
+
+	one := filepath.Join(dir, "cert.pem")
+	assert.NoErrorT(t, os.WriteFile(one, EncodeCertificatePEM(certs[0]), 0644))
+	cert, err := ParseCertificatePEMFile(one)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, cert.Equal(certs[0]), "lib: expected the certificate to match the original")
+
+	_, err = ParseCertificatePEMFile(filepath.Join(dir, "missing.pem"))
+	assert.BoolT(t, err != nil && strings.HasPrefix(err.Error(), "failed to load certificate from disk"),
+		fmt.Sprintf("lib: expected a loading error, have %v", err))
+}
+
+func TestIssuedBy(t *testing.T) {
+	now := time.Now()
+	root, rootKey := issueTestCert(t, testCA("root", now.Add(OneYear)), nil, nil, nil)
+	other, _ := issueTestCert(t, testCA("other root", now.Add(OneYear)), nil, nil, nil)
+	leaf, _ := issueTestCert(t, testCA("leaf", now.Add(OneDay)), nil, root, rootKey)
+
+	ok, err := IssuedBy(leaf, root)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, ok, "lib: expected the leaf to have been issued by the root")
+
+	ok, err = IssuedBy(leaf, other)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, !ok, "lib: expected the leaf not to have been issued by another root")
+
+	// A certificate with the right key identifier but the wrong key
+	// is a real problem rather than a mismatch.
+	impostor := *other
+	impostor.SubjectKeyId = root.SubjectKeyId
+	ok, err = IssuedBy(leaf, &impostor)
+	assert.ErrorT(t, err, "lib: expected an error when the signature doesn't verify")
+	assert.BoolT(t, !ok, "lib: expected the leaf not to have been issued by the impostor")
+}
+
+func TestValidatePrivateKeySize(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoErrorT(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoErrorT(t, err)
+
+	assert.NoErrorT(t, ValidatePrivateKeySize(ecKey, 2048, 256))
+	assert.ErrorT(t, ValidatePrivateKeySize(ecKey, 2048, 384), "lib: expected a P-256 key to fail a 384-bit minimum")
+	assert.NoErrorT(t, ValidatePrivateKeySize(rsaKey, 1024, 384))
+	assert.ErrorT(t, ValidatePrivateKeySize(rsaKey, 2048, 256), "lib: expected a 1024-bit RSA key to fail a 2048-bit minimum")
+
+	der, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	assert.NoErrorT(t, err)
+	path := filepath.Join(t.TempDir(), "key.der")
+	assert.NoErrorT(t, os.WriteFile(path, der, 0600))
+
+	_, err = LoadPrivateKeyWithSizeCheck(path, 2048, 256)
+	assert.ErrorT(t, err, "lib: expected loading a 1024-bit RSA key to fail a 2048-bit minimum")
+	_, err = LoadPrivateKeyWithSizeCheck(path, 1024, 256)
+	assert.NoErrorT(t, err)
+}
+
+func TestCRLDistributionPointURLs(t *testing.T) {
+	urls := []string{"http://crl.example.net/a.crl", "http://crl.example.net/b.crl"}
+	cert, _ := issueTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "cdp"},
+		CRLDistributionPoints: urls,
+	}, nil, nil, nil)
+
+	have, err := CRLDistributionPointURLs(cert)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, strings.Join(have, " ") == strings.Join(urls, " "),
+		fmt.Sprintf("lib: expected CRL URLs %v, have %v", urls, have))
+
+	// Force the fallback path, which parses the extension itself.
+	cert.CRLDistributionPoints = nil
+	have, err = CRLDistributionPointURLs(cert)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, strings.Join(have, " ") == strings.Join(urls, " "),
+		fmt.Sprintf("lib: expected CRL URLs %v from the extension, have %v", urls, have))
+
+	cert.Extensions = []pkix.Extension{{Id: crlDistributionPointsOid, Value: []byte{0x30, 0x03, 0x30}}}
+	_, err = CRLDistributionPointURLs(cert)
+	assert.ErrorT(t, err, "lib: expected an error for a malformed extension")
+}
+
+func TestEncodeCertificatesDER(t *testing.T) {
+	certs, err := ReadCertificates([]byte(testCerts))
+	assert.NoErrorT(t, err)
+
+	ders := EncodeCertificatesDER(certs)
+	assert.BoolT(t, len(ders) == len(certs), fmt.Sprintf("lib: expected %d DER certificates, have %d", len(certs), len(ders)))
+	for i, der := range ders {
+		cert, err := x509.ParseCertificate(der)
+		assert.NoErrorT(t, err)
+		assert.BoolT(t, cert.Equal(certs[i]), "lib: expected the DER certificate to match the original")
+	}
+}
+
+func TestLoadCertificateChain(t *testing.T) {
+	now := time.Now()
+	root, rootKey := issueTestCert(t, testCA("root", now.Add(OneYear)), nil, nil, nil)
+	inter, interKey := issueTestCert(t, testCA("intermediate", now.Add(OneYear)), nil, root, rootKey)
+	leaf, _ := issueTestCert(t, testCA("leaf", now.Add(OneDay)), nil, inter, interKey)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chain.pem")
+	assert.NoErrorT(t, os.WriteFile(path, EncodeCertificatesPEM([]*x509.Certificate{inter, leaf}), 0644))
+
+	cert, intermediates, err := LoadCertificateChain(path)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, cert.Equal(leaf), "lib: expected the leaf certificate first")
+	assert.BoolT(t, len(intermediates) == 1 && intermediates[0].Equal(inter), "lib: expected one intermediate")
+
+	path = filepath.Join(dir, "leaf.pem")
+	assert.NoErrorT(t, os.WriteFile(path, EncodeCertificatePEM(leaf), 0644))
+	cert, intermediates, err = LoadCertificateChain(path)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, cert.Equal(leaf), "lib: expected the leaf certificate")
+	assert.BoolT(t, len(intermediates) == 0, "lib: expected no intermediates")
+
+	path = filepath.Join(dir, "empty.pem")
+	assert.NoErrorT(t, os.WriteFile(path, nil, 0644))
+	_, _, err = LoadCertificateChain(path)
+	assert.ErrorT(t, err, "lib: expected an error for an empty bundle")
+}
+
+func TestSubjectAlternativeNames(t *testing.T) {
+	uri, err := url.Parse("spiffe://example.net/service")
+	assert.NoErrorT(t, err)
+
+	cert := &x509.Certificate{
+		DNSNames:       []string{"www.example.net", "example.net"},
+		IPAddresses:    []net.IP{net.ParseIP("127.0.0.1")},
+		EmailAddresses: []string{"admin@example.net"},
+		URIs:           []*url.URL{uri},
+	}
+
+	expected := []string{
+		"DNS:example.net",
+		"DNS:www.example.net",
+		"EMAIL:admin@example.net",
+		"IP:127.0.0.1",
+		"URI:spiffe://example.net/service",
+	}
+
+	sans := SubjectAlternativeNames(cert)
+	assert.BoolT(t, len(sans) == len(expected), fmt.Sprintf("lib: expected %d SANs, have %v", len(expected), sans))
+	for i := range expected {
+		assert.BoolT(t, sans[i] == expected[i], fmt.Sprintf("lib: expected SAN %d to be %s, have %s", i, expected[i], sans[i]))
+	}
+
+	sans = SubjectAlternativeNames(&x509.Certificate{})
+	assert.BoolT(t, len(sans) == 0, "lib: expected no SANs")
+}
+
+func TestKeyAlgorithm(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoErrorT(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoErrorT(t, err)
+
+	tests := []struct {
+		pub      crypto.PublicKey
+		expected string
+	}{
+		{rsaKey.Public(), "RSA (1024 bits)"},
+		{newTestKey(t).Public(), "ECDSA P-256"},
+		{edKey.Public(), "Ed25519"},
+		{"not a key", "unknown (string)"},
+	}
+
+	for _, test := range tests {
+		alg := KeyAlgorithm(test.pub)
+		assert.BoolT(t, alg == test.expected, fmt.Sprintf("certlib: expected %s, have %s", test.expected, alg))
+	}
+}
+
+func TestSignerAlgo(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoErrorT(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoErrorT(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoErrorT(t, err)
+
+	tests := []struct {
+		key      crypto.Signer
+		expected x509.SignatureAlgorithm
+	}{
+		{rsaKey, x509.SHA256WithRSA},
+		{ecKey, x509.ECDSAWithSHA384},
+		{edKey, x509.PureEd25519},
+	}
+
+	for _, test := range tests {
+		der, err := x509.MarshalPKCS8PrivateKey(test.key)
+		assert.NoErrorT(t, err)
+
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		key, err := ParsePrivateKeyPEM(keyPEM)
+		assert.NoErrorT(t, err)
+
+		alg := SignerAlgo(key)
+		assert.BoolT(t, alg == test.expected, fmt.Sprintf("lib: expected signature algorithm %s, have %s", test.expected, alg))
+	}
+}
@@ -0,0 +1,120 @@
+package certlib
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+)
+
+// ErrChainCycle is returned by BuildChain when every path from the
+// leaf loops back on itself without reaching a root.
+var ErrChainCycle = errors.New("certificate chain contains a cycle")
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// chainNode is a certificate in the search tree built by BuildChain.
+type chainNode struct {
+	cert   *x509.Certificate
+	parent *chainNode
+	depth  int
+}
+
+func (n *chainNode) chain() []*x509.Certificate {
+	chain := make([]*x509.Certificate, n.depth+1)
+	for ; n != nil; n = n.parent {
+		chain[n.depth] = n.cert
+	}
+
+	return chain
+}
+
+// BuildChain links leaf to its issuers among candidates, checking
+// each signature with CheckSignatureFrom, and returns the chain
+// ordered from the leaf to the root. If there are several ways to
+// reach a self-signed root, the shortest is returned. If no root can
+// be reached, the longest chain that could be built is returned,
+// ending in the last certificate whose issuer wasn't among the
+// candidates. ErrChainCycle is returned, wrapped in a verification
+// error, if every path from the leaf loops back on itself.
+func BuildChain(leaf *x509.Certificate, candidates []*x509.Certificate) ([]*x509.Certificate, error) {
+	var deadEnd *chainNode
+
+	seen := map[string]bool{string(leaf.Raw): true}
+	queue := []*chainNode{{cert: leaf}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if isSelfSigned(n.cert) {
+			return n.chain(), nil
+		}
+
+		issued := false
+		for _, candidate := range candidates {
+			if !bytes.Equal(n.cert.RawIssuer, candidate.RawSubject) {
+				continue
+			}
+
+			if n.cert.CheckSignatureFrom(candidate) != nil {
+				continue
+			}
+
+			issued = true
+			if seen[string(candidate.Raw)] {
+				continue
+			}
+			seen[string(candidate.Raw)] = true
+
+			queue = append(queue, &chainNode{
+				cert:   candidate,
+				parent: n,
+				depth:  n.depth + 1,
+			})
+		}
+
+		if !issued && (deadEnd == nil || n.depth > deadEnd.depth) {
+			deadEnd = n
+		}
+	}
+
+	// Without a root or a dead end, every path led back to a
+	// certificate that had already been visited.
+	if deadEnd == nil {
+		return nil, certerr.VerifyError(certerr.ErrorSourceCertificate, ErrChainCycle)
+	}
+
+	return deadEnd.chain(), nil
+}
+
+// BuildChainFromDir is like BuildChain, but uses the certificates in
+// the .pem and .crt files in dir as the candidates.
+func BuildChainFromDir(leaf *x509.Certificate, dir string) ([]*x509.Certificate, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, certerr.LoadingError(certerr.ErrorSourceCertificate, err)
+	}
+
+	var candidates []*x509.Certificate
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".pem" && ext != ".crt") {
+			continue
+		}
+
+		certs, err := LoadCertificates(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, certerr.LoadingError(certerr.ErrorSourceCertificate, err)
+		}
+		candidates = append(candidates, certs...)
+	}
+
+	return BuildChain(leaf, candidates)
+}
@@ -0,0 +1,83 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+func chainNames(chain []*x509.Certificate) []string {
+	var names []string
+	for _, cert := range chain {
+		names = append(names, cert.Subject.CommonName)
+	}
+
+	return names
+}
+
+func TestBuildChain(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+	root, rootKey := issueTestCert(t, testCA("root", later), nil, nil, nil)
+	other, otherKey := issueTestCert(t, testCA("other", later), nil, root, rootKey)
+
+	// The intermediate is cross-signed: once directly by the root,
+	// and once by another intermediate.
+	inter, interKey := issueTestCert(t, testCA("inter", later), nil, root, rootKey)
+	crossInter, _ := issueTestCert(t, testCA("inter", later), interKey, other, otherKey)
+	leaf, _ := issueTestCert(t, testCA("leaf", later), nil, inter, interKey)
+
+	chain, err := BuildChain(leaf, []*x509.Certificate{crossInter, other, root, inter})
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(chain) == 3, fmt.Sprintf("lib: expected the shortest chain, have %v", chainNames(chain)))
+	assert.BoolT(t, chain[0] == leaf && chain[1] == inter && chain[2] == root,
+		fmt.Sprintf("lib: expected the chain leaf, inter, root; have %v", chainNames(chain)))
+
+	// Without the root, the chain stops at the intermediate.
+	chain, err = BuildChain(leaf, []*x509.Certificate{inter})
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(chain) == 2 && chain[1] == inter,
+		fmt.Sprintf("lib: expected a partial chain, have %v", chainNames(chain)))
+
+	chain, err = BuildChain(root, []*x509.Certificate{inter, other})
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(chain) == 1 && chain[0] == root, "lib: expected a self-signed leaf to be its own chain")
+}
+
+func TestBuildChainCycle(t *testing.T) {
+	// Each of a and b is issued by the other, so neither can be
+	// issued first; templates stand in for the issuers.
+	later := time.Now().Add(time.Hour)
+	aKey, bKey := newTestKey(t), newTestKey(t)
+	a, _ := issueTestCert(t, testCA("a", later), aKey, testCA("b", later), bKey)
+	b, _ := issueTestCert(t, testCA("b", later), bKey, testCA("a", later), aKey)
+	leaf, _ := issueTestCert(t, testCA("leaf", later), nil, a, aKey)
+
+	_, err := BuildChain(leaf, []*x509.Certificate{a, b})
+	assert.BoolT(t, errors.Is(err, ErrChainCycle), fmt.Sprintf("lib: expected a cycle error, have %v", err))
+}
+
+func TestBuildChainFromDir(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+	root, rootKey := issueTestCert(t, testCA("root", later), nil, nil, nil)
+	inter, interKey := issueTestCert(t, testCA("inter", later), nil, root, rootKey)
+	leaf, _ := issueTestCert(t, testCA("leaf", later), nil, inter, interKey)
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "root.pem"), EncodeCertificatePEM(root), 0644)
+	assert.NoErrorT(t, err)
+	err = os.WriteFile(filepath.Join(dir, "inter.crt"), EncodeCertificatePEM(inter), 0644)
+	assert.NoErrorT(t, err)
+	err = os.WriteFile(filepath.Join(dir, "README"), []byte("not a certificate"), 0644)
+	assert.NoErrorT(t, err)
+
+	chain, err := BuildChainFromDir(leaf, dir)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(chain) == 3 && chain[2].Equal(root),
+		fmt.Sprintf("lib: expected the chain leaf, inter, root; have %v", chainNames(chain)))
+}
@@ -0,0 +1,45 @@
+package certlib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+func TestMarshalCSR(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoErrorT(t, err)
+
+	tmpl := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "test.example.net"},
+		DNSNames: []string{"test.example.net"},
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, priv)
+	assert.NoErrorT(t, err)
+
+	csr, err := x509.ParseCertificateRequest(der)
+	assert.NoErrorT(t, err)
+
+	path := filepath.Join(t.TempDir(), "test.csr")
+	assert.NoErrorT(t, MarshalCSRToFile(csr, path))
+
+	in, err := os.ReadFile(path)
+	assert.NoErrorT(t, err)
+
+	parsed, rest, err := ParseCSR(in)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(rest) == 0, "certlib: expected no trailing data after the CSR")
+	assert.BoolT(t, parsed.Subject.CommonName == "test.example.net",
+		"certlib: CSR subject wasn't preserved")
+
+	_, err = MarshalCSR(&x509.CertificateRequest{})
+	assert.ErrorT(t, err, "certlib: expected an error marshaling an empty CSR")
+}
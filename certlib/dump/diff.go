@@ -0,0 +1,104 @@
+package dump
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// jsonFields decodes a JSON object, returning its keys in the order
+// they appear along with the compacted value of each.
+func jsonFields(data []byte) ([]string, map[string]string, error) {
+	var keys []string
+	values := map[string]string{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("dump: unexpected JSON token %v", tok)
+		}
+
+		var value json.RawMessage
+		if err = dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+
+		var buf bytes.Buffer
+		if err = json.Compact(&buf, value); err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, key)
+		values[key] = buf.String()
+	}
+
+	return keys, values, nil
+}
+
+// DiffCerts compares the JSON representations of two certificates (as
+// produced by CertToJSON) field by field, and writes each field to w
+// in a unified diff style: unchanged fields are prefixed with two
+// spaces, and a field that differs is shown as a "-" line for a and a
+// "+" line for b. Fields that are missing from one certificate are
+// only shown for the other. It returns true if any field differs.
+func DiffCerts(w io.Writer, a, b *x509.Certificate) (bool, error) {
+	aJSON, err := CertToJSON(a, false)
+	if err != nil {
+		return false, err
+	}
+
+	bJSON, err := CertToJSON(b, false)
+	if err != nil {
+		return false, err
+	}
+
+	aKeys, aValues, err := jsonFields(aJSON)
+	if err != nil {
+		return false, err
+	}
+
+	bKeys, bValues, err := jsonFields(bJSON)
+	if err != nil {
+		return false, err
+	}
+
+	// Fields that only b has are shown after all of a's fields.
+	keys := aKeys
+	for _, key := range bKeys {
+		if _, ok := aValues[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	differ := false
+	for _, key := range keys {
+		aValue, aOK := aValues[key]
+		bValue, bOK := bValues[key]
+		if aOK && bOK && aValue == bValue {
+			fmt.Fprintf(w, "  %s: %s\n", key, aValue)
+			continue
+		}
+
+		differ = true
+		if aOK {
+			fmt.Fprintf(w, "- %s: %s\n", key, aValue)
+		}
+		if bOK {
+			fmt.Fprintf(w, "+ %s: %s\n", key, bValue)
+		}
+	}
+
+	return differ, nil
+}
@@ -0,0 +1,86 @@
+package dump
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffCerts(t *testing.T) {
+	a := newTestCert(t, testTemplate("a.example.net"), nil)
+	b := newTestCert(t, testTemplate("b.example.net"), nil)
+
+	buf := &bytes.Buffer{}
+	differ, err := DiffCerts(buf, a, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if differ {
+		t.Fatalf("dump: a certificate shouldn't differ from itself:\n%s", buf)
+	}
+
+	buf.Reset()
+	differ, err = DiffCerts(buf, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !differ {
+		t.Fatal("dump: expected the certificates to differ")
+	}
+
+	out := buf.String()
+	for _, line := range []string{
+		`- subject: "/a.example.net"`,
+		`+ subject: "/b.example.net"`,
+		`  serial_number: "42"`,
+		`  key_usages: ["digital signature"]`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Fatalf("dump: expected the line '%s' in the diff:\n%s", line, out)
+		}
+	}
+}
+
+func TestDiffCertsRekey(t *testing.T) {
+	notBefore := time.Now().Truncate(time.Second)
+	issue := func(ext pkix.Extension) *x509.Certificate {
+		tmpl := testTemplate("rekey.example.net")
+		tmpl.NotBefore = notBefore
+		tmpl.ExtraExtensions = []pkix.Extension{ext}
+		return newTestCert(t, tmpl, nil)
+	}
+
+	// An extension that certdump doesn't otherwise understand.
+	oid := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+	a := issue(pkix.Extension{Id: oid, Value: []byte{0x05, 0x00}})
+	b := issue(pkix.Extension{Id: oid, Value: []byte{0x01, 0x01, 0xff}})
+
+	buf := &bytes.Buffer{}
+	differ, err := DiffCerts(buf, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !differ {
+		t.Fatalf("dump: expected a rekeyed certificate to differ:\n%s", buf)
+	}
+
+	out := "\n" + buf.String()
+	for _, prefix := range []string{"- spki_sha256: ", "+ spki_sha256: ", "- extensions: ", "+ extensions: "} {
+		if !strings.Contains(out, "\n"+prefix) {
+			t.Fatalf("dump: expected a '%s' line in the diff:\n%s", prefix, out)
+		}
+	}
+
+	for _, field := range []string{"public_key", "public_key_size", "subject", "not_after"} {
+		if !strings.Contains(out, "\n  "+field+": ") {
+			t.Fatalf("dump: expected %s to be unchanged:\n%s", field, out)
+		}
+	}
+}
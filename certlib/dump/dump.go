@@ -0,0 +1,387 @@
+// Package dump prints human-readable descriptions of certificates and
+// related structures.
+package dump
+
+import (
+	"crypto"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"strings"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+	"github.com/kr/text"
+)
+
+// OneTrueDateFormat is the default format used for dates.
+const OneTrueDateFormat = "2006-01-02T15:04:05-0700"
+
+// DateFormat is the Go time format used to display dates.
+var DateFormat = OneTrueDateFormat
+
+// following two lifted from CFSSL, (replace-regexp "\(.+\): \(.+\),"
+// "\2: \1,")
+
+var keyUsage = map[x509.KeyUsage]string{
+	x509.KeyUsageDigitalSignature:  "digital signature",
+	x509.KeyUsageContentCommitment: "content committment",
+	x509.KeyUsageKeyEncipherment:   "key encipherment",
+	x509.KeyUsageKeyAgreement:      "key agreement",
+	x509.KeyUsageDataEncipherment:  "data encipherment",
+	x509.KeyUsageCertSign:          "cert sign",
+	x509.KeyUsageCRLSign:           "crl sign",
+	x509.KeyUsageEncipherOnly:      "encipher only",
+	x509.KeyUsageDecipherOnly:      "decipher only",
+}
+
+var extKeyUsages = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                        "any",
+	x509.ExtKeyUsageServerAuth:                 "server auth",
+	x509.ExtKeyUsageClientAuth:                 "client auth",
+	x509.ExtKeyUsageCodeSigning:                "code signing",
+	x509.ExtKeyUsageEmailProtection:            "s/mime",
+	x509.ExtKeyUsageIPSECEndSystem:             "ipsec end system",
+	x509.ExtKeyUsageIPSECTunnel:                "ipsec tunnel",
+	x509.ExtKeyUsageIPSECUser:                  "ipsec user",
+	x509.ExtKeyUsageTimeStamping:               "timestamping",
+	x509.ExtKeyUsageOCSPSigning:                "ocsp signing",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto: "microsoft sgc",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:  "netscape sgc",
+}
+
+func sigAlgoPK(a x509.SignatureAlgorithm) string {
+	switch a {
+
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA:
+		return "RSA"
+	case x509.ECDSAWithSHA1, x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+		return "ECDSA"
+	case x509.DSAWithSHA1, x509.DSAWithSHA256:
+		return "DSA"
+	case x509.PureEd25519:
+		return "Ed25519"
+	default:
+		return "unknown public key algorithm"
+	}
+}
+
+func sigAlgoHash(a x509.SignatureAlgorithm) string {
+	switch a {
+	case x509.MD2WithRSA:
+		return "MD2"
+	case x509.MD5WithRSA:
+		return "MD5"
+	case x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1:
+		return "SHA1"
+	case x509.SHA256WithRSA, x509.ECDSAWithSHA256, x509.DSAWithSHA256:
+		return "SHA256"
+	case x509.SHA384WithRSA, x509.ECDSAWithSHA384:
+		return "SHA384"
+	case x509.SHA512WithRSA, x509.ECDSAWithSHA512, x509.PureEd25519:
+		return "SHA512"
+	default:
+		return "unknown hash algorithm"
+	}
+}
+
+const maxLine = 78
+
+func makeIndent(n int) string {
+	s := "    "
+	for i := 0; i < n; i++ {
+		s += "        "
+	}
+	return s
+}
+
+func indentLen(n int) int {
+	return 4 + (8 * n)
+}
+
+// this isn't real efficient, but that's not a problem here
+func wrap(s string, indent int) string {
+	if indent > 3 {
+		indent = 3
+	}
+
+	wrapped := text.Wrap(s, maxLine)
+	lines := strings.SplitN(wrapped, "\n", 2)
+	if len(lines) == 1 {
+		return lines[0]
+	}
+
+	if (maxLine - indentLen(indent)) <= 0 {
+		panic("too much indentation")
+	}
+
+	rest := strings.Join(lines[1:], " ")
+	wrapped = text.Wrap(rest, maxLine-indentLen(indent))
+	return lines[0] + "\n" + text.Indent(wrapped, makeIndent(indent))
+}
+
+func dumpHex(in []byte) string {
+	var s string
+	for i := range in {
+		s += fmt.Sprintf("%02X:", in[i])
+	}
+
+	return strings.Trim(s, ":")
+}
+
+func certPublic(cert *x509.Certificate) string {
+	return publicKeyString(cert.PublicKey)
+}
+
+func publicKeyString(pub crypto.PublicKey) string {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return "ECDSA-prime256v1"
+		case elliptic.P384():
+			return "ECDSA-secp384r1"
+		case elliptic.P521():
+			return "ECDSA-secp521r1"
+		default:
+			return "ECDSA (unknown curve)"
+		}
+	case *dsa.PublicKey:
+		return "DSA"
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return "Unknown"
+	}
+}
+
+// DisplayKeyInfo writes a description of cert's public key to w: its
+// algorithm and size or curve, the public exponent for RSA keys, and
+// the key usages the certificate allows.
+func DisplayKeyInfo(w io.Writer, cert *x509.Certificate) {
+	fmt.Fprintln(w, "\tPublic key:")
+	fmt.Fprintf(w, "\t\tAlgorithm: %s\n", certlib.KeyAlgorithm(cert.PublicKey))
+	if pub, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+		fmt.Fprintf(w, "\t\tPublic exponent: %d\n", pub.E)
+	}
+	fmt.Fprintf(w, "\t\tKey usages: %s\n", keyUsages(cert.KeyUsage))
+}
+
+func displayName(name pkix.Name) string {
+	var ns []string
+
+	if name.CommonName != "" {
+		ns = append(ns, name.CommonName)
+	}
+
+	for i := range name.Country {
+		ns = append(ns, fmt.Sprintf("C=%s", name.Country[i]))
+	}
+
+	for i := range name.Organization {
+		ns = append(ns, fmt.Sprintf("O=%s", name.Organization[i]))
+	}
+
+	for i := range name.OrganizationalUnit {
+		ns = append(ns, fmt.Sprintf("OU=%s", name.OrganizationalUnit[i]))
+	}
+
+	for i := range name.Locality {
+		ns = append(ns, fmt.Sprintf("L=%s", name.Locality[i]))
+	}
+
+	for i := range name.Province {
+		ns = append(ns, fmt.Sprintf("ST=%s", name.Province[i]))
+	}
+
+	if len(ns) > 0 {
+		return "/" + strings.Join(ns, "/")
+	}
+
+	return "*** no subject information ***"
+}
+
+func keyUsages(ku x509.KeyUsage) string {
+	return strings.Join(keyUsageList(ku), ", ")
+}
+
+func extUsage(ext []x509.ExtKeyUsage) string {
+	return strings.Join(extKeyUsageList(ext), ", ")
+}
+
+func showBasicConstraints(w io.Writer, cert *x509.Certificate) {
+	fmt.Fprintf(w, "\tBasic constraints: ")
+	if cert.BasicConstraintsValid {
+		fmt.Fprintf(w, "valid")
+	} else {
+		fmt.Fprintf(w, "invalid")
+	}
+
+	if cert.IsCA {
+		fmt.Fprintf(w, ", is a CA certificate")
+	}
+
+	if (cert.MaxPathLen == 0 && cert.MaxPathLenZero) || (cert.MaxPathLen > 0) {
+		fmt.Fprintf(w, ", max path length %d", cert.MaxPathLen)
+	}
+
+	fmt.Fprintf(w, "\n")
+}
+
+func wrapPrint(w io.Writer, text string, indent int) {
+	tabs := ""
+	for i := 0; i < indent; i++ {
+		tabs += "\t"
+	}
+
+	fmt.Fprintf(w, tabs+"%s\n", wrap(text, indent))
+}
+
+func showOCSPServers(w io.Writer, cert *x509.Certificate) {
+	l := len(cert.OCSPServer)
+	if l > 0 {
+		title := "OCSP server"
+		if l > 1 {
+			title += "s"
+		}
+		wrapPrint(w, title+":\n", 1)
+		for _, ocspServer := range cert.OCSPServer {
+			wrapPrint(w, fmt.Sprintf("- %s\n", ocspServer), 2)
+		}
+	}
+}
+
+func showCRLDistributionPoints(w io.Writer, cert *x509.Certificate) {
+	l := len(cert.CRLDistributionPoints)
+	if l > 0 {
+		title := "CRL distribution point"
+		if l > 1 {
+			title += "s"
+		}
+		wrapPrint(w, title+":\n", 1)
+		for _, cdp := range cert.CRLDistributionPoints {
+			wrapPrint(w, fmt.Sprintf("- %s\n", cdp), 2)
+		}
+	}
+}
+
+// DisplayRevocationInfo writes the OCSP servers and CRL distribution
+// points advertised by cert to w.
+func DisplayRevocationInfo(w io.Writer, cert *x509.Certificate) {
+	showOCSPServers(w, cert)
+	showCRLDistributionPoints(w, cert)
+}
+
+// DisplayCert writes a human-readable description of cert to w. If
+// showHash is true, the SHA-256 hash of the certificate's DER
+// contents is included. If verbose is true, the certificate's CRL
+// distribution points and all of its extensions are also included.
+func DisplayCert(w io.Writer, cert *x509.Certificate, showHash, verbose bool) {
+	fmt.Fprintln(w, "CERTIFICATE")
+	if showHash {
+		fmt.Fprintln(w, wrap(fmt.Sprintf("SHA256: %x", sha256.Sum256(cert.Raw)), 0))
+	}
+	fmt.Fprintln(w, wrap("Subject: "+displayName(cert.Subject), 0))
+	fmt.Fprintln(w, wrap("Issuer: "+displayName(cert.Issuer), 0))
+	fmt.Fprintf(w, "\tSignature algorithm: %s / %s\n", sigAlgoPK(cert.SignatureAlgorithm),
+		sigAlgoHash(cert.SignatureAlgorithm))
+	fmt.Fprintln(w, "Details:")
+	DisplayKeyInfo(w, cert)
+	fmt.Fprintf(w, "\tSerial number: %s\n", cert.SerialNumber)
+
+	if len(cert.AuthorityKeyId) > 0 {
+		fmt.Fprintf(w, "\t%s\n", wrap("AKI: "+dumpHex(cert.AuthorityKeyId), 1))
+	}
+	if len(cert.SubjectKeyId) > 0 {
+		fmt.Fprintf(w, "\t%s\n", wrap("SKI: "+dumpHex(cert.SubjectKeyId), 1))
+	}
+
+	wrapPrint(w, "Valid from: "+cert.NotBefore.Format(DateFormat), 1)
+	fmt.Fprintf(w, "\t     until: %s\n", cert.NotAfter.Format(DateFormat))
+
+	if len(cert.ExtKeyUsage) > 0 {
+		fmt.Fprintf(w, "\tExtended usages: %s\n", extUsage(cert.ExtKeyUsage))
+	}
+
+	showBasicConstraints(w, cert)
+
+	validNames := certlib.SubjectAlternativeNames(cert)
+	sans := fmt.Sprintf("SANs (%d): %s\n", len(validNames), strings.Join(validNames, ", "))
+	wrapPrint(w, sans, 1)
+
+	l := len(cert.IssuingCertificateURL)
+	if l != 0 {
+		var aia string
+		if l == 1 {
+			aia = "AIA"
+		} else {
+			aia = "AIAs"
+		}
+		wrapPrint(w, fmt.Sprintf("%d %s:", l, aia), 1)
+		for _, url := range cert.IssuingCertificateURL {
+			wrapPrint(w, url, 2)
+		}
+	}
+
+	if verbose {
+		DisplayRevocationInfo(w, cert)
+		DisplayExtensions(w, cert)
+	} else {
+		showOCSPServers(w, cert)
+	}
+}
+
+var extensionNames = map[string]string{
+	"2.5.29.14": "subject key identifier",
+	"2.5.29.15": "key usage",
+	"2.5.29.17": "subject alternative name",
+	"2.5.29.35": "authority key identifier",
+	"2.5.29.37": "extended key usage",
+}
+
+// DisplayExtensions writes every extension in cert to w, including
+// those that DisplayCert doesn't otherwise show, with its OID, whether
+// it is critical, and its raw value in hex.
+func DisplayExtensions(w io.Writer, cert *x509.Certificate) {
+	fmt.Fprintf(w, "\tExtensions (%d):\n", len(cert.Extensions))
+	for _, ext := range cert.Extensions {
+		oid := ext.Id.String()
+		title := oid
+		if name, ok := extensionNames[oid]; ok {
+			title = fmt.Sprintf("%s (%s)", oid, name)
+		}
+
+		if ext.Critical {
+			title += ", critical"
+		}
+
+		wrapPrint(w, title+":", 2)
+		wrapPrint(w, dumpHex(ext.Value), 3)
+	}
+}
+
+// briefSubjectLength is the maximum length of the subject shown by
+// DisplayCertBrief.
+const briefSubjectLength = 40
+
+// DisplayCertBrief writes a one-line summary of cert to w, suitable
+// for monitoring output. The tab-separated fields are the serial
+// number, subject (truncated to 40 characters), expiry, public key
+// type and size, and signature algorithm.
+func DisplayCertBrief(w io.Writer, cert *x509.Certificate) {
+	subject := []rune(displayName(cert.Subject))
+	if len(subject) > briefSubjectLength {
+		subject = append(subject[:briefSubjectLength-3], []rune("...")...)
+	}
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", cert.SerialNumber, string(subject),
+		cert.NotAfter.Format(DateFormat), certPublic(cert), cert.SignatureAlgorithm)
+}
@@ -0,0 +1,230 @@
+package dump
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTemplate returns a template for a certificate for name.
+func testTemplate(name string) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+}
+
+// newTestCert issues a self-signed certificate from tmpl for key. If
+// key is nil, a new P-256 key is generated.
+func newTestCert(t *testing.T, tmpl *x509.Certificate, key crypto.Signer) *x509.Certificate {
+	if key == nil {
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func TestDisplayCertBrief(t *testing.T) {
+	certs := []*x509.Certificate{
+		newTestCert(t, testTemplate("short.example.net"), nil),
+		newTestCert(t, testTemplate("a-very-long-host-name-that-will-not-fit.example.net"), nil),
+	}
+
+	buf := &bytes.Buffer{}
+	for _, cert := range certs {
+		DisplayCertBrief(buf, cert)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(certs) {
+		t.Fatalf("dump: expected %d lines, have %d:\n%s", len(certs), len(lines), buf)
+	}
+
+	expected := []string{"42", "/short.example.net", "2030-01-02T03:04:05+0000", "ECDSA-prime256v1", "ECDSA-SHA256"}
+	if fields := strings.Split(lines[0], "\t"); strings.Join(fields, "|") != strings.Join(expected, "|") {
+		t.Fatalf("dump: expected fields %q, have %q", expected, fields)
+	}
+
+	fields := strings.Split(lines[1], "\t")
+	if len(fields) != 5 {
+		t.Fatalf("dump: expected five fields, have %q", fields)
+	}
+
+	if len(fields[1]) != briefSubjectLength || !strings.HasSuffix(fields[1], "...") {
+		t.Fatalf("dump: expected the subject to be truncated, have '%s'", fields[1])
+	}
+}
+
+func TestDisplayExtensions(t *testing.T) {
+	cert := newTestCert(t, testTemplate("ext.example.net"), nil)
+	cert.Extensions = append(cert.Extensions, pkix.Extension{
+		Id:       asn1.ObjectIdentifier{1, 2, 3, 4},
+		Critical: true,
+		Value:    []byte{0xde, 0xad},
+	})
+
+	buf := &bytes.Buffer{}
+	DisplayExtensions(buf, cert)
+	out := buf.String()
+	for _, expected := range []string{"2.5.29.15 (key usage), critical:", "1.2.3.4, critical:", "DE:AD"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("dump: expected output to contain '%s':\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	DisplayCert(buf, cert, true, false)
+	if strings.Contains(buf.String(), "Extensions") {
+		t.Fatal("dump: extensions should only be shown in verbose mode")
+	}
+
+	buf.Reset()
+	DisplayCert(buf, cert, false, true)
+	if !strings.Contains(buf.String(), "1.2.3.4, critical:") {
+		t.Fatalf("dump: expected extensions in verbose mode:\n%s", buf)
+	}
+}
+
+func TestDisplayKeyInfo(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cert     *x509.Certificate
+		expected []string
+	}{
+		{newTestCert(t, testTemplate("rsa"), rsaKey), []string{"Algorithm: RSA (2048 bits)", "Public exponent: 65537"}},
+		{newTestCert(t, testTemplate("p256"), nil), []string{"Algorithm: ECDSA P-256"}},
+		{newTestCert(t, testTemplate("p384"), p384Key), []string{"Algorithm: ECDSA P-384"}},
+		{newTestCert(t, testTemplate("ed25519"), edKey), []string{"Algorithm: Ed25519"}},
+	}
+
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		DisplayKeyInfo(buf, test.cert)
+		out := buf.String()
+
+		expected := append(test.expected, "Key usages: digital signature")
+		for _, line := range expected {
+			if !strings.Contains(out, line) {
+				t.Fatalf("dump: expected output for %s to contain '%s':\n%s", test.cert.Subject.CommonName, line, out)
+			}
+		}
+
+		if test.cert.PublicKeyAlgorithm != x509.RSA && strings.Contains(out, "Public exponent") {
+			t.Fatalf("dump: only RSA keys should have a public exponent:\n%s", out)
+		}
+	}
+}
+
+func TestDisplayRevocationInfo(t *testing.T) {
+	tmpl := testTemplate("revocation.example.net")
+	tmpl.OCSPServer = []string{"http://ocsp.example.net"}
+	tmpl.CRLDistributionPoints = []string{
+		"http://crl1.example.net/ca.crl",
+		"http://crl2.example.net/ca.crl",
+	}
+	cert := newTestCert(t, tmpl, nil)
+
+	buf := &bytes.Buffer{}
+	DisplayRevocationInfo(buf, cert)
+	out := buf.String()
+	for _, expected := range []string{
+		"OCSP server:",
+		"- http://ocsp.example.net",
+		"CRL distribution points:",
+		"- http://crl1.example.net/ca.crl",
+		"- http://crl2.example.net/ca.crl",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("dump: expected output to contain '%s':\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	DisplayCert(buf, cert, false, false)
+	if strings.Contains(buf.String(), "CRL distribution") {
+		t.Fatal("dump: CRL distribution points should only be shown in verbose mode")
+	}
+
+	buf.Reset()
+	DisplayCert(buf, cert, false, true)
+	if !strings.Contains(buf.String(), "- http://crl2.example.net/ca.crl") {
+		t.Fatalf("dump: expected CRL distribution points in verbose mode:\n%s", buf)
+	}
+}
+
+func TestDisplayCertEd25519(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	DisplayCert(buf, newTestCert(t, testTemplate("ed25519.example.net"), key), false, false)
+	if !strings.Contains(buf.String(), "Signature algorithm: Ed25519 / SHA512") {
+		t.Fatalf("dump: expected an Ed25519 signature algorithm:\n%s", buf)
+	}
+}
+
+func TestDisplayCertKeyIdentifiers(t *testing.T) {
+	tmpl := testTemplate("kid.example.net")
+	tmpl.SubjectKeyId = []byte{0x01, 0x02, 0xab, 0xcd}
+	tmpl.AuthorityKeyId = []byte{0xfe, 0xdc, 0x00, 0x10}
+	tmpl.BasicConstraintsValid = true
+	cert := newTestCert(t, tmpl, nil)
+
+	buf := &bytes.Buffer{}
+	DisplayCert(buf, cert, false, false)
+	for _, expected := range []string{"\tAKI: FE:DC:00:10\n", "\tSKI: 01:02:AB:CD\n"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Fatalf("dump: expected %q in output:\n%s", expected, buf)
+		}
+	}
+
+	buf.Reset()
+	DisplayCert(buf, newTestCert(t, testTemplate("nokid.example.net"), nil), false, false)
+	if strings.Contains(buf.String(), "AKI:") || strings.Contains(buf.String(), "SKI:") {
+		t.Fatalf("dump: expected no key identifiers for a certificate without them:\n%s", buf)
+	}
+}
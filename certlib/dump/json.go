@@ -0,0 +1,149 @@
+package dump
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+)
+
+// certJSON is the JSON form of a certificate produced by CertToJSON.
+type certJSON struct {
+	SHA256                string    `json:"sha256,omitempty"`
+	Subject               string    `json:"subject"`
+	Issuer                string    `json:"issuer"`
+	SerialNumber          string    `json:"serial_number"`
+	SignatureAlgorithm    string    `json:"signature_algorithm"`
+	PublicKey             string    `json:"public_key"`
+	PublicKeySize         int       `json:"public_key_size,omitempty"`
+	PublicExponent        int       `json:"public_exponent,omitempty"`
+	SPKISHA256            string    `json:"spki_sha256"`
+	AKI                   string    `json:"aki,omitempty"`
+	SKI                   string    `json:"ski,omitempty"`
+	NotBefore             time.Time `json:"not_before"`
+	NotAfter              time.Time `json:"not_after"`
+	KeyUsages             []string  `json:"key_usages,omitempty"`
+	ExtKeyUsages          []string  `json:"ext_key_usages,omitempty"`
+	IsCA                  bool      `json:"is_ca"`
+	MaxPathLen            *int      `json:"max_path_len,omitempty"`
+	SANs                  []string  `json:"sans,omitempty"`
+	IssuingCertificateURL []string  `json:"issuing_certificate_urls,omitempty"`
+	OCSPServers           []string  `json:"ocsp_servers,omitempty"`
+	CRLDistributionPoints []string  `json:"crl_distribution_points,omitempty"`
+	Extensions            []extJSON `json:"extensions,omitempty"`
+}
+
+// extJSON describes a certificate extension by its OID and the
+// SHA-256 hash of its value, so that changes to any extension, even
+// ones certdump doesn't understand, show up in a diff.
+type extJSON struct {
+	OID         string `json:"oid"`
+	Critical    bool   `json:"critical,omitempty"`
+	ValueSHA256 string `json:"value_sha256"`
+}
+
+func extensionList(cert *x509.Certificate) []extJSON {
+	var exts []extJSON
+	for _, ext := range cert.Extensions {
+		sum := sha256.Sum256(ext.Value)
+		exts = append(exts, extJSON{
+			OID:         ext.Id.String(),
+			Critical:    ext.Critical,
+			ValueSHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	return exts
+}
+
+func keyUsageList(ku x509.KeyUsage) []string {
+	var uses []string
+	for u, s := range keyUsage {
+		if (ku & u) != 0 {
+			uses = append(uses, s)
+		}
+	}
+	sort.Strings(uses)
+
+	return uses
+}
+
+func extKeyUsageList(ext []x509.ExtKeyUsage) []string {
+	var uses []string
+	for i := range ext {
+		uses = append(uses, extKeyUsages[ext[i]])
+	}
+	sort.Strings(uses)
+
+	return uses
+}
+
+func newCertJSON(cert *x509.Certificate, showHash bool) *certJSON {
+	cj := &certJSON{
+		Subject:               displayName(cert.Subject),
+		Issuer:                displayName(cert.Issuer),
+		SerialNumber:          cert.SerialNumber.String(),
+		SignatureAlgorithm:    cert.SignatureAlgorithm.String(),
+		PublicKey:             certPublic(cert),
+		PublicKeySize:         certlib.KeyLength(cert.PublicKey),
+		AKI:                   hex.EncodeToString(cert.AuthorityKeyId),
+		SKI:                   hex.EncodeToString(cert.SubjectKeyId),
+		NotBefore:             cert.NotBefore,
+		NotAfter:              cert.NotAfter,
+		KeyUsages:             keyUsageList(cert.KeyUsage),
+		ExtKeyUsages:          extKeyUsageList(cert.ExtKeyUsage),
+		IsCA:                  cert.BasicConstraintsValid && cert.IsCA,
+		SANs:                  certlib.SubjectAlternativeNames(cert),
+		IssuingCertificateURL: cert.IssuingCertificateURL,
+		OCSPServers:           cert.OCSPServer,
+		CRLDistributionPoints: cert.CRLDistributionPoints,
+		Extensions:            extensionList(cert),
+	}
+
+	spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	cj.SPKISHA256 = hex.EncodeToString(spki[:])
+
+	if showHash {
+		sum := sha256.Sum256(cert.Raw)
+		cj.SHA256 = hex.EncodeToString(sum[:])
+	}
+
+	if pub, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+		cj.PublicExponent = pub.E
+	}
+
+	if (cert.MaxPathLen == 0 && cert.MaxPathLenZero) || (cert.MaxPathLen > 0) {
+		maxPathLen := cert.MaxPathLen
+		cj.MaxPathLen = &maxPathLen
+	}
+
+	return cj
+}
+
+// CertToJSON returns a JSON object describing cert, with the same
+// fields that DisplayCert shows, plus the SHA-256 hash of its
+// SubjectPublicKeyInfo and a list of its extensions. If showHash is
+// true, the SHA-256 hash of the certificate's DER contents is
+// included.
+func CertToJSON(cert *x509.Certificate, showHash bool) ([]byte, error) {
+	return json.Marshal(newCertJSON(cert, showHash))
+}
+
+// DisplayCertJSON writes the JSON description of cert from CertToJSON
+// to w, followed by a newline.
+func DisplayCertJSON(w io.Writer, cert *x509.Certificate, showHash bool) error {
+	out, err := CertToJSON(cert, showHash)
+	if err != nil {
+		return err
+	}
+
+	out = append(out, '\n')
+	_, err = w.Write(out)
+	return err
+}
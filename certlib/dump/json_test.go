@@ -0,0 +1,59 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCertToJSON(t *testing.T) {
+	cert := newTestCert(t, testTemplate("json.example.net"), nil)
+
+	out, err := CertToJSON(cert, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cj certJSON
+	if err = json.Unmarshal(out, &cj); err != nil {
+		t.Fatal(err)
+	}
+
+	if cj.Subject != "/json.example.net" {
+		t.Fatalf("dump: expected subject '/json.example.net', have '%s'", cj.Subject)
+	}
+
+	if cj.SerialNumber != "42" {
+		t.Fatalf("dump: expected serial number 42, have %s", cj.SerialNumber)
+	}
+
+	if cj.PublicKey != "ECDSA-prime256v1" || cj.PublicKeySize != 256 {
+		t.Fatalf("dump: expected a P-256 public key, have %s (%d bits)", cj.PublicKey, cj.PublicKeySize)
+	}
+
+	if !cj.NotAfter.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("dump: unexpected expiry %s", cj.NotAfter)
+	}
+
+	if len(cj.KeyUsages) != 1 || cj.KeyUsages[0] != "digital signature" {
+		t.Fatalf("dump: expected the digital signature key usage, have %v", cj.KeyUsages)
+	}
+
+	if cj.SHA256 != "" {
+		t.Fatal("dump: the hash should only be included when requested")
+	}
+
+	buf := &bytes.Buffer{}
+	if err = DisplayCertJSON(buf, cert, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = json.Unmarshal(buf.Bytes(), &cj); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cj.SHA256) != 64 {
+		t.Fatalf("dump: expected a SHA-256 hash, have '%s'", cj.SHA256)
+	}
+}
@@ -0,0 +1,59 @@
+package dump
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"golang.org/x/crypto/pkcs12"
+)
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// DumpPKCS12 writes a description of the contents of a PKCS #12
+// file to w: a summary line, the type and size of any private keys,
+// and each certificate.
+func DumpPKCS12(w io.Writer, data []byte, password string) error {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return certerr.DecodeError(certerr.ErrorSourceCertificate, err)
+	}
+
+	var certs []*x509.Certificate
+	var keys []string
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return certerr.ParsingError(certerr.ErrorSourceCertificate, err)
+			}
+			certs = append(certs, cert)
+		case "PRIVATE KEY":
+			key, err := certlib.ParsePrivateKeyDER(block.Bytes)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, publicKeyString(key.Public()))
+		}
+	}
+
+	fmt.Fprintf(w, "PKCS#12: %s, %s\n", plural(len(certs), "certificate"), plural(len(keys), "private key"))
+	for _, key := range keys {
+		fmt.Fprintln(w, "PRIVATE KEY")
+		fmt.Fprintf(w, "\tType: %s\n", key)
+	}
+
+	for _, cert := range certs {
+		DisplayCert(w, cert, false, false)
+	}
+
+	return nil
+}
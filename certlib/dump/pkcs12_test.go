@@ -0,0 +1,38 @@
+package dump
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testdata/test.p12 contains an RSA leaf certificate and its key,
+// plus the ECDSA CA certificate that issued it. The password is
+// "password".
+func TestDumpPKCS12(t *testing.T) {
+	data, err := os.ReadFile("testdata/test.p12")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = DumpPKCS12(buf, data, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "PKCS#12: 2 certificates, 1 private key\n") {
+		t.Fatalf("dump: unexpected summary line in output:\n%s", out)
+	}
+
+	for _, expected := range []string{"Type: RSA-2048", "Subject: /test.example.net", "Subject: /Test CA"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("dump: expected output to contain '%s':\n%s", expected, out)
+		}
+	}
+
+	if err = DumpPKCS12(buf, data, "wrong password"); err == nil {
+		t.Fatal("dump: expected an error with the wrong password")
+	}
+}
@@ -0,0 +1,129 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+)
+
+// Encoding identifies how a set of certificates is serialised.
+type Encoding uint8
+
+const (
+	EncodingUnknown Encoding = iota
+	EncodingPEM
+	EncodingDER
+	EncodingPKCS7
+	EncodingPKCS12
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingPEM:
+		return "PEM"
+	case EncodingDER:
+		return "DER"
+	case EncodingPKCS7:
+		return "PKCS #7"
+	case EncodingPKCS12:
+		return "PKCS #12"
+	default:
+		return "unknown"
+	}
+}
+
+// oidPKCS7 is the arc under which the PKCS #7 content types are
+// defined.
+var oidPKCS7 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7}
+
+// DetectEncoding reports how data is encoded, without fully parsing
+// it. PEM is recognised by a PEM block anywhere in data, so that
+// bundles with leading comments or text are detected too. The DER
+// encodings all start with a SEQUENCE, and are told apart by its
+// first element: a certificate starts with another SEQUENCE (the
+// TBSCertificate), a PKCS #7 ContentInfo with a PKCS #7 content type,
+// and a PKCS #12 PFX with its version number.
+func DetectEncoding(data []byte) Encoding {
+	if block, _ := pem.Decode(data); block != nil {
+		return EncodingPEM
+	}
+
+	var outer asn1.RawValue
+	if _, err := asn1.Unmarshal(data, &outer); err != nil {
+		return EncodingUnknown
+	}
+
+	if outer.Class != asn1.ClassUniversal || outer.Tag != asn1.TagSequence {
+		return EncodingUnknown
+	}
+
+	var first asn1.RawValue
+	if _, err := asn1.Unmarshal(outer.Bytes, &first); err != nil || first.Class != asn1.ClassUniversal {
+		return EncodingUnknown
+	}
+
+	switch first.Tag {
+	case asn1.TagSequence:
+		return EncodingDER
+	case asn1.TagInteger:
+		return EncodingPKCS12
+	case asn1.TagOID:
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(first.FullBytes, &oid); err != nil {
+			return EncodingUnknown
+		}
+
+		if len(oid) == len(oidPKCS7)+1 && oid[:len(oidPKCS7)].Equal(oidPKCS7) {
+			return EncodingPKCS7
+		}
+	}
+
+	return EncodingUnknown
+}
+
+// LoadCertificatesAuto parses the certificates in data, using
+// DetectEncoding to decide how. The password is only used for
+// PKCS #12 data.
+func LoadCertificatesAuto(data []byte, password string) ([]*x509.Certificate, error) {
+	switch DetectEncoding(data) {
+	case EncodingPEM:
+		return ParseCertificatesPEM(data)
+	case EncodingDER, EncodingPKCS7, EncodingPKCS12:
+		certs, _, err := ParseCertificatesDER(data, password)
+		return certs, err
+	default:
+		return nil, certerr.DecodeError(certerr.ErrorSourceCertificate,
+			errors.New("unrecognised certificate encoding"))
+	}
+}
+
+// PoolFromBytes returns a CertPool holding the certificates in data,
+// which may be one or more PEM blocks, one or more DER certificates,
+// or a PKCS #7 bundle. Unlike PEMToCertPool, an input that contains
+// no certificates is an error.
+func PoolFromBytes(data []byte) (*x509.CertPool, error) {
+	if DetectEncoding(data) == EncodingPKCS12 {
+		return nil, certerr.DecodeError(certerr.ErrorSourceCertificate,
+			errors.New("PKCS #12 data can't be used as a certificate pool"))
+	}
+
+	certs, err := LoadCertificatesAuto(data, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(certs) == 0 {
+		return nil, certerr.DecodeError(certerr.ErrorSourceCertificate,
+			errors.New("no certificates found"))
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+
+	return pool, nil
+}
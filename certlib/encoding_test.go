@@ -0,0 +1,134 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"os"
+	"testing"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+// degeneratePKCS7 wraps certs in a certificate-only PKCS #7 SignedData
+// structure, as found in .p7b files.
+func degeneratePKCS7(t *testing.T, certs ...*x509.Certificate) []byte {
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+
+	dataInfo, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+	}{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}})
+	assert.NoErrorT(t, err)
+
+	signedData, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		ContentInfo      asn1.RawValue
+		Certificates     asn1.RawValue
+		SignerInfos      asn1.RawValue
+	}{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true},
+		ContentInfo:      asn1.RawValue{FullBytes: dataInfo},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      asn1.RawValue{Tag: asn1.TagSet, IsCompound: true},
+	})
+	assert.NoErrorT(t, err)
+
+	contentInfo, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2},
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+	assert.NoErrorT(t, err)
+	return contentInfo
+}
+
+func TestDetectEncoding(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+	root, key := issueTestCert(t, testCA("encoding root", later), nil, nil, nil)
+	leaf, _ := issueTestCert(t, testCA("encoding leaf", later), nil, root, key)
+
+	pemCerts := EncodeCertificatesPEM([]*x509.Certificate{leaf, root})
+	p7 := degeneratePKCS7(t, leaf, root)
+	p12, err := os.ReadFile("dump/testdata/test.p12")
+	assert.NoErrorT(t, err)
+
+	tests := []struct {
+		data     []byte
+		expected Encoding
+	}{
+		{pemCerts, EncodingPEM},
+		{append([]byte("\n  "), pemCerts...), EncodingPEM},
+		{append([]byte("# Test CA bundle\n"), pemCerts...), EncodingPEM},
+		{leaf.Raw, EncodingDER},
+		{append(leaf.Raw, root.Raw...), EncodingDER},
+		{p7, EncodingPKCS7},
+		{p12, EncodingPKCS12},
+		{nil, EncodingUnknown},
+		{[]byte("not a certificate"), EncodingUnknown},
+		{pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw})[5:], EncodingUnknown},
+	}
+
+	for i, test := range tests {
+		if encoding := DetectEncoding(test.data); encoding != test.expected {
+			t.Fatalf("certlib: test %d: expected %s, have %s", i, test.expected, encoding)
+		}
+	}
+
+	commented := append([]byte("# Test CA bundle\nSubject: encoding leaf\n\n"), pemCerts...)
+	for _, data := range [][]byte{pemCerts, commented, append(leaf.Raw, root.Raw...), p7} {
+		certs, err := LoadCertificatesAuto(data, "")
+		assert.NoErrorT(t, err)
+		assert.BoolT(t, len(certs) == 2 && certs[0].Equal(leaf) && certs[1].Equal(root),
+			"certlib: expected the leaf and root certificates")
+	}
+
+	_, err = LoadCertificatesAuto([]byte("not a certificate"), "")
+	assert.ErrorT(t, err)
+}
+
+func TestPoolFromBytes(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+	root, key := issueTestCert(t, testCA("pool root", later), nil, nil, nil)
+	leaf, _ := issueTestCert(t, testCA("pool leaf", later), nil, root, key)
+
+	one := x509.NewCertPool()
+	one.AddCert(leaf)
+	both := x509.NewCertPool()
+	both.AddCert(leaf)
+	both.AddCert(root)
+
+	tests := []struct {
+		data     []byte
+		expected *x509.CertPool
+	}{
+		{EncodeCertificatesPEM([]*x509.Certificate{leaf}), one},
+		{EncodeCertificatesPEM([]*x509.Certificate{leaf, root}), both},
+		{append([]byte("# Test CA bundle\n\n"), EncodeCertificatesPEM([]*x509.Certificate{leaf, root})...), both},
+		{leaf.Raw, one},
+		{degeneratePKCS7(t, leaf, root), both},
+	}
+
+	for i, test := range tests {
+		pool, err := PoolFromBytes(test.data)
+		assert.NoErrorT(t, err)
+		if !pool.Equal(test.expected) {
+			t.Fatalf("certlib: test %d: pool doesn't hold the expected certificates", i)
+		}
+	}
+
+	p12, err := os.ReadFile("dump/testdata/test.p12")
+	assert.NoErrorT(t, err)
+
+	for _, data := range [][]byte{nil, []byte("not a certificate"), p12} {
+		_, err = PoolFromBytes(data)
+		assert.ErrorT(t, err)
+	}
+}
@@ -0,0 +1,69 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ExpiryWarning describes a certificate in a pool that has expired
+// or will expire soon.
+type ExpiryWarning struct {
+	Cert    *x509.Certificate
+	Expired bool
+}
+
+// String returns a one-line description of the warning.
+func (w ExpiryWarning) String() string {
+	if w.Expired {
+		return fmt.Sprintf("%s expired at %s", w.Cert.Subject, w.Cert.NotAfter.Format(time.RFC3339))
+	}
+
+	return fmt.Sprintf("%s expires at %s", w.Cert.Subject, w.Cert.NotAfter.Format(time.RFC3339))
+}
+
+// CertPoolWithExpiry loads the PEM certificates in pemCerts into a
+// pool with PEMToCertPool, then checks each of them for expiry with
+// CheckExpiry.
+func CertPoolWithExpiry(pemCerts []byte, leeway time.Duration, warn func(msg string)) (*x509.CertPool, []ExpiryWarning, error) {
+	pool, err := PEMToCertPool(pemCerts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certs, err := ReadCertificates(pemCerts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pool, CheckExpiry(certs, leeway, warn), nil
+}
+
+// CheckExpiry returns a warning for every certificate in certs that
+// has expired or will expire within leeway; each is also passed to
+// warn, or to log.Printf if warn is nil.
+func CheckExpiry(certs []*x509.Certificate, leeway time.Duration, warn func(msg string)) []ExpiryWarning {
+	if warn == nil {
+		warn = func(msg string) {
+			log.Printf("%s", msg)
+		}
+	}
+
+	now := time.Now()
+	var warnings []ExpiryWarning
+	for _, cert := range certs {
+		if now.Add(leeway).Before(cert.NotAfter) {
+			continue
+		}
+
+		w := ExpiryWarning{
+			Cert:    cert,
+			Expired: now.After(cert.NotAfter),
+		}
+		warn(w.String())
+		warnings = append(warnings, w)
+	}
+
+	return warnings
+}
@@ -0,0 +1,58 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"fmt"
+	"testing"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+func TestCertPoolWithExpiry(t *testing.T) {
+	now := time.Now()
+	good, _ := issueTestCert(t, testCA("good", now.Add(OneYear)), nil, nil, nil)
+	soon, _ := issueTestCert(t, testCA("soon", now.Add(OneDay)), nil, nil, nil)
+	expired, _ := issueTestCert(t, testCA("expired", now.Add(-OneDay)), nil, nil, nil)
+
+	pemCerts := EncodeCertificatesPEM([]*x509.Certificate{good, soon, expired})
+
+	var msgs []string
+	pool, warnings, err := CertPoolWithExpiry(pemCerts, 30*OneDay, func(msg string) {
+		msgs = append(msgs, msg)
+	})
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, pool != nil, "lib: expected a certificate pool")
+	assert.BoolT(t, len(warnings) == 2, fmt.Sprintf("lib: expected two warnings, have %d", len(warnings)))
+	assert.BoolT(t, len(msgs) == 2, fmt.Sprintf("lib: expected two warning messages, have %d", len(msgs)))
+	assert.BoolT(t, warnings[0].Cert.Equal(soon) && !warnings[0].Expired,
+		"lib: expected a warning for the soon to expire certificate")
+	assert.BoolT(t, warnings[1].Cert.Equal(expired) && warnings[1].Expired,
+		"lib: expected a warning for the expired certificate")
+
+	_, warnings, err = CertPoolWithExpiry(pemCerts, 0, func(string) {})
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(warnings) == 1, fmt.Sprintf("lib: expected one warning, have %d", len(warnings)))
+
+	_, _, err = CertPoolWithExpiry([]byte("not a certificate"), 0, nil)
+	assert.ErrorT(t, err)
+}
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Now()
+	good, _ := issueTestCert(t, testCA("good", now.Add(OneYear)), nil, nil, nil)
+	expired, _ := issueTestCert(t, testCA("expired", now.Add(-OneDay)), nil, nil, nil)
+
+	// A DER certificate doesn't need to go through PEMToCertPool
+	// to be checked.
+	certs, err := ReadCertificates(expired.Raw)
+	assert.NoErrorT(t, err)
+
+	var msgs []string
+	warnings := CheckExpiry(append(certs, good), 30*OneDay, func(msg string) {
+		msgs = append(msgs, msg)
+	})
+	assert.BoolT(t, len(warnings) == 1 && warnings[0].Cert.Equal(expired) && warnings[0].Expired,
+		"lib: expected a warning for the expired certificate")
+	assert.BoolT(t, len(msgs) == 1, fmt.Sprintf("lib: expected one warning message, have %d", len(msgs)))
+}
@@ -35,7 +35,9 @@ SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
 import (
 	"bytes"
 	"crypto"
+	"crypto/dsa"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/tls"
@@ -46,11 +48,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
 	"git.wntrmute.dev/kyle/goutils/certlib/pkcs7"
+	"git.wntrmute.dev/kyle/goutils/fileutil"
 
 	ct "github.com/google/certificate-transparency-go"
 	cttls "github.com/google/certificate-transparency-go/tls"
@@ -103,6 +107,23 @@ func KeyLength(key interface{}) int {
 	return 0
 }
 
+// KeyAlgorithm returns a short description of pub's algorithm and
+// size, such as "RSA (2048 bits)" or "ECDSA P-256".
+func KeyAlgorithm(pub crypto.PublicKey) string {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA (%d bits)", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + pub.Curve.Params().Name
+	case *dsa.PublicKey:
+		return fmt.Sprintf("DSA (%d bits)", pub.P.BitLen())
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("unknown (%T)", pub)
+	}
+}
+
 // ExpiryTime returns the time when the certificate chain is expired.
 func ExpiryTime(chain []*x509.Certificate) (notAfter time.Time) {
 	if len(chain) == 0 {
@@ -118,6 +139,101 @@ func ExpiryTime(chain []*x509.Certificate) (notAfter time.Time) {
 	return
 }
 
+// NormalizeChain returns a copy of chain ordered from the leaf to the
+// root: the leaf is the certificate that didn't issue any of the
+// others, and each following certificate is the issuer of the one
+// before it. Certificates that aren't part of that path are kept at
+// the end in their original order.
+func NormalizeChain(chain []*x509.Certificate) []*x509.Certificate {
+	if len(chain) < 2 {
+		return chain
+	}
+
+	issued := func(issuer *x509.Certificate) bool {
+		for _, cert := range chain {
+			if cert != issuer && bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+				return true
+			}
+		}
+		return false
+	}
+
+	leaf := 0
+	for i, cert := range chain {
+		if !issued(cert) {
+			leaf = i
+			break
+		}
+	}
+
+	used := make([]bool, len(chain))
+	used[leaf] = true
+	normalized := []*x509.Certificate{chain[leaf]}
+	for current := chain[leaf]; ; {
+		next := -1
+		for i, cert := range chain {
+			if !used[i] && bytes.Equal(current.RawIssuer, cert.RawSubject) {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			break
+		}
+
+		used[next] = true
+		current = chain[next]
+		normalized = append(normalized, current)
+	}
+
+	for i, cert := range chain {
+		if !used[i] {
+			normalized = append(normalized, cert)
+		}
+	}
+
+	return normalized
+}
+
+// LeafExpiryTime returns the expiry time of the leaf certificate in
+// the chain. Unlike ExpiryTime, it doesn't consider the rest of the
+// chain.
+func LeafExpiryTime(chain []*x509.Certificate) time.Time {
+	if len(chain) == 0 {
+		return time.Time{}
+	}
+
+	return NormalizeChain(chain)[0].NotAfter
+}
+
+// SubjectAlternativeNames returns every subject alternative name in
+// cert as a sorted slice. Each name is prefixed with its type: for
+// example, "DNS:example.net", "IP:127.0.0.1", "EMAIL:user@example.net",
+// or "URI:https://example.net/".
+func SubjectAlternativeNames(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses)+
+		len(cert.EmailAddresses)+len(cert.URIs))
+	for _, name := range cert.DNSNames {
+		sans = append(sans, "DNS:"+name)
+	}
+
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, "IP:"+ip.String())
+	}
+
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, "EMAIL:"+email)
+	}
+
+	for _, uri := range cert.URIs {
+		sans = append(sans, "URI:"+uri.String())
+	}
+
+	sort.Strings(sans)
+	return sans
+}
+
 // MonthsValid returns the number of months for which a certificate is valid.
 func MonthsValid(c *x509.Certificate) int {
 	issued := c.NotBefore
@@ -251,6 +367,21 @@ func EncodeCertificatePEM(cert *x509.Certificate) []byte {
 	return EncodeCertificatesPEM([]*x509.Certificate{cert})
 }
 
+// EncodeCertificateDER returns the DER encoding of a certificate.
+func EncodeCertificateDER(cert *x509.Certificate) []byte {
+	return cert.Raw
+}
+
+// EncodeCertificatesDER returns the DER encoding of each certificate.
+func EncodeCertificatesDER(certs []*x509.Certificate) [][]byte {
+	ders := make([][]byte, 0, len(certs))
+	for _, cert := range certs {
+		ders = append(ders, EncodeCertificateDER(cert))
+	}
+
+	return ders
+}
+
 // ParseCertificatesPEM parses a sequence of PEM-encoded certificate and returns them,
 // can handle PEM encoded PKCS #7 structures.
 func ParseCertificatesPEM(certsPEM []byte) ([]*x509.Certificate, error) {
@@ -274,28 +405,47 @@ func ParseCertificatesPEM(certsPEM []byte) ([]*x509.Certificate, error) {
 	return certs, nil
 }
 
+// ParseCertificatesPEMFile reads the file at path and parses the
+// PEM-encoded certificates in it with ParseCertificatesPEM.
+func ParseCertificatesPEMFile(path string) ([]*x509.Certificate, error) {
+	in, err := os.ReadFile(path)
+	if err != nil {
+		return nil, certerr.LoadingError(certerr.ErrorSourceCertificate, err)
+	}
+
+	return ParseCertificatesPEM(in)
+}
+
 // ParseCertificatesDER parses a DER encoding of a certificate object and possibly private key,
-// either PKCS #7, PKCS #12, or raw x509.
+// either PKCS #7, PKCS #12, or raw x509. The format is chosen with DetectEncoding.
 func ParseCertificatesDER(certsDER []byte, password string) (certs []*x509.Certificate, key crypto.Signer, err error) {
 	certsDER = bytes.TrimSpace(certsDER)
-	pkcs7data, err := pkcs7.ParsePKCS7(certsDER)
-	if err != nil {
-		var pkcs12data interface{}
-		certs = make([]*x509.Certificate, 1)
-		pkcs12data, certs[0], err = pkcs12.Decode(certsDER, password)
+	switch DetectEncoding(certsDER) {
+	case EncodingPKCS7:
+		pkcs7data, err := pkcs7.ParsePKCS7(certsDER)
 		if err != nil {
-			certs, err = x509.ParseCertificates(certsDER)
-			if err != nil {
-				return nil, nil, certerr.DecodeError(certerr.ErrorSourceCertificate, err)
-			}
-		} else {
-			key = pkcs12data.(crypto.Signer)
+			return nil, nil, certerr.DecodeError(certerr.ErrorSourceCertificate, err)
 		}
-	} else {
+
 		if pkcs7data.ContentInfo != "SignedData" {
 			return nil, nil, certerr.DecodeError(certerr.ErrorSourceCertificate, errors.New("can only extract certificates from signed data content info"))
 		}
 		certs = pkcs7data.Content.SignedData.Certificates
+	case EncodingPKCS12:
+		var pkcs12data interface{}
+		var cert *x509.Certificate
+		pkcs12data, cert, err = pkcs12.Decode(certsDER, password)
+		if err != nil {
+			return nil, nil, certerr.DecodeError(certerr.ErrorSourceCertificate, err)
+		}
+
+		certs = []*x509.Certificate{cert}
+		key = pkcs12data.(crypto.Signer)
+	default:
+		certs, err = x509.ParseCertificates(certsDER)
+		if err != nil {
+			return nil, nil, certerr.DecodeError(certerr.ErrorSourceCertificate, err)
+		}
 	}
 	if certs == nil {
 		return nil, key, certerr.DecodeError(certerr.ErrorSourceCertificate, errors.New("no certificates decoded"))
@@ -316,6 +466,62 @@ func ParseSelfSignedCertificatePEM(certPEM []byte) (*x509.Certificate, error) {
 	return cert, nil
 }
 
+// IssuedBy reports whether cert was issued by issuer. If both
+// certificates have key identifiers, a mismatch between cert's AKI and
+// issuer's SKI means cert wasn't issued by issuer; otherwise, the
+// issuer name must match issuer's subject. In that case, the signature
+// is checked, and an error is returned if it doesn't verify.
+func IssuedBy(cert, issuer *x509.Certificate) (bool, error) {
+	if len(cert.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 {
+		if !bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId) {
+			return false, nil
+		}
+	} else if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false, nil
+	}
+
+	if err := cert.CheckSignatureFrom(issuer); err != nil {
+		return false, certerr.VerifyError(certerr.ErrorSourceCertificate,
+			fmt.Errorf("%s matches issuer %s, but its signature doesn't verify: %w",
+				cert.Subject, issuer.Subject, err))
+	}
+
+	return true, nil
+}
+
+// ParseCertificatePEMFile reads the file at path and parses the single
+// PEM-encoded certificate in it with ParseCertificatePEM.
+func ParseCertificatePEMFile(path string) (*x509.Certificate, error) {
+	in, err := os.ReadFile(path)
+	if err != nil {
+		return nil, certerr.LoadingError(certerr.ErrorSourceCertificate, err)
+	}
+
+	return ParseCertificatePEM(in)
+}
+
+// SelfSignedPool builds a certificate pool from the self-signed
+// certificates in certs. Any certificate that fails the self-signature
+// check is left out of the pool and reported in the returned error;
+// the pool is still returned, so callers may choose to treat those
+// certificates as intermediates instead.
+func SelfSignedPool(certs []*x509.Certificate) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	var errs []error
+	for _, cert := range certs {
+		if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+			errs = append(errs, certerr.VerifyError(certerr.ErrorSourceCertificate,
+				fmt.Errorf("%s is not self-signed: %w", cert.Subject, err)))
+			continue
+		}
+
+		pool.AddCert(cert)
+	}
+
+	return pool, errors.Join(errs...)
+}
+
 // ParseCertificatePEM parses and returns a PEM-encoded certificate,
 // can handle PEM encoded PKCS #7 structures.
 func ParseCertificatePEM(certPEM []byte) (*x509.Certificate, error) {
@@ -441,7 +647,7 @@ func ParseCSR(in []byte) (csr *x509.CertificateRequest, rest []byte, err error)
 	p, rest := pem.Decode(in)
 	if p != nil {
 		if p.Type != "NEW CERTIFICATE REQUEST" && p.Type != "CERTIFICATE REQUEST" {
-			return nil, rest, certerr.ParsingError(certerr.ErrorSourceCSR, certerr.ErrInvalidPEMType(p.Type, "NEW CERTIFICATE REQUEST", "CERTIFICATE REQUEST"))
+			return nil, rest, certerr.ParsingError(certerr.ErrorSourceCSR, certerr.NewPEMTypeError(p.Type, "NEW CERTIFICATE REQUEST", "CERTIFICATE REQUEST"))
 		}
 
 		csr, err = x509.ParseCertificateRequest(p.Bytes)
@@ -478,6 +684,29 @@ func ParseCSRPEM(csrPEM []byte) (*x509.CertificateRequest, error) {
 	return csrObject, nil
 }
 
+// MarshalCSR PEM-encodes a certificate signing request.
+func MarshalCSR(csr *x509.CertificateRequest) ([]byte, error) {
+	if csr == nil || len(csr.Raw) == 0 {
+		return nil, certerr.DecodeError(certerr.ErrorSourceCSR, errors.New("CSR is empty"))
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: csr.Raw,
+	}), nil
+}
+
+// MarshalCSRToFile PEM-encodes a certificate signing request and
+// writes it to path.
+func MarshalCSRToFile(csr *x509.CertificateRequest, path string) error {
+	csrPEM, err := MarshalCSR(csr)
+	if err != nil {
+		return err
+	}
+
+	return fileutil.AtomicWriteFile(path, csrPEM, 0644)
+}
+
 // SignerAlgo returns an X.509 signature algorithm from a crypto.Signer.
 func SignerAlgo(priv crypto.Signer) x509.SignatureAlgorithm {
 	switch pub := priv.Public().(type) {
@@ -504,6 +733,8 @@ func SignerAlgo(priv crypto.Signer) x509.SignatureAlgorithm {
 		default:
 			return x509.ECDSAWithSHA1
 		}
+	case ed25519.PublicKey:
+		return x509.PureEd25519
 	default:
 		return x509.UnknownSignatureAlgorithm
 	}
@@ -605,6 +836,85 @@ func SCTListFromOCSPResponse(response *ocsp.Response) ([]ct.SignedCertificateTim
 	return sctList, err
 }
 
+// sctListExtOid is the ObjectIdentifier of the embedded SCT list
+// certificate extension defined in RFC 6962 section 3.3.
+var sctListExtOid = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// SCTListFromCertificate extracts the SCTs embedded in a certificate,
+// returning an empty list if the certificate has no SCT list
+// extension.
+func SCTListFromCertificate(cert *x509.Certificate) ([]ct.SignedCertificateTimestamp, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(sctListExtOid) {
+			continue
+		}
+
+		var serializedSCTList []byte
+		rest, err := asn1.Unmarshal(ext.Value, &serializedSCTList)
+		if err != nil {
+			return nil, certerr.ParsingError(certerr.ErrorSourceSCTList, err)
+		} else if len(rest) != 0 {
+			return nil, certerr.ParsingError(certerr.ErrorSourceSCTList, errors.New("SCT list extension contained trailing garbage"))
+		}
+
+		return DeserializeSCTList(serializedSCTList)
+	}
+
+	return nil, nil
+}
+
+// crlDistributionPointsOid is the ObjectIdentifier of the CRL
+// distribution points extension defined in RFC 5280 section 4.2.1.13.
+var crlDistributionPointsOid = asn1.ObjectIdentifier{2, 5, 29, 31}
+
+type distributionPoint struct {
+	DistributionPoint distributionPointName `asn1:"optional,tag:0"`
+	Reason            asn1.BitString        `asn1:"optional,tag:1"`
+	CRLIssuer         asn1.RawValue         `asn1:"optional,tag:2"`
+}
+
+type distributionPointName struct {
+	FullName     []asn1.RawValue  `asn1:"optional,tag:0"`
+	RelativeName pkix.RDNSequence `asn1:"optional,tag:1"`
+}
+
+// CRLDistributionPointURLs returns the CRL URLs in a certificate. If
+// the Go parser didn't fill in cert.CRLDistributionPoints, the CRL
+// distribution points extension is parsed directly.
+func CRLDistributionPointURLs(cert *x509.Certificate) ([]string, error) {
+	if len(cert.CRLDistributionPoints) > 0 {
+		return cert.CRLDistributionPoints, nil
+	}
+
+	var urls []string
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(crlDistributionPointsOid) {
+			continue
+		}
+
+		var cdp []distributionPoint
+		rest, err := asn1.Unmarshal(ext.Value, &cdp)
+		if err != nil {
+			return nil, certerr.ParsingError(certerr.ErrorSourceCertificate, err)
+		} else if len(rest) != 0 {
+			return nil, certerr.ParsingError(certerr.ErrorSourceCertificate,
+				errors.New("CRL distribution points extension contained trailing garbage"))
+		}
+
+		for _, dp := range cdp {
+			for _, name := range dp.DistributionPoint.FullName {
+				// A uniformResourceIdentifier is tagged [6]
+				// in a GeneralName.
+				if name.Class == asn1.ClassContextSpecific && name.Tag == 6 {
+					urls = append(urls, string(name.Bytes))
+				}
+			}
+		}
+	}
+
+	return urls, nil
+}
+
 // ReadBytes reads a []byte either from a file or an environment variable.
 // If valFile has a prefix of 'env:', the []byte is read from the environment
 // using the subsequent name. If the prefix is 'file:' the []byte is read from
@@ -0,0 +1,57 @@
+package certlib
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+)
+
+// PublicKeyBytesEqual reports whether a and b are the same public
+// key, by comparing their DER-encoded SubjectPublicKeyInfo. It
+// supports any key type that x509.MarshalPKIXPublicKey does (RSA,
+// ECDSA, and Ed25519); an error is returned for any other type.
+func PublicKeyBytesEqual(a, b crypto.PublicKey) (bool, error) {
+	aDER, err := x509.MarshalPKIXPublicKey(a)
+	if err != nil {
+		return false, err
+	}
+
+	bDER, err := x509.MarshalPKIXPublicKey(b)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(aDER, bDER), nil
+}
+
+// MatchKeys reports whether priv is the private key for cert's public
+// key. Keys of different types never match; an error is returned if
+// the private key's type isn't supported.
+func MatchKeys(cert *x509.Certificate, priv crypto.Signer) (bool, error) {
+	switch pub := priv.Public().(type) {
+	case *rsa.PublicKey:
+		certPub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return false, nil
+		}
+		return pub.N.Cmp(certPub.N) == 0 && pub.E == certPub.E, nil
+	case *ecdsa.PublicKey:
+		certPub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return false, nil
+		}
+		return pub.Curve == certPub.Curve && pub.X.Cmp(certPub.X) == 0 && pub.Y.Cmp(certPub.Y) == 0, nil
+	case ed25519.PublicKey:
+		certPub, ok := cert.PublicKey.(ed25519.PublicKey)
+		if !ok {
+			return false, nil
+		}
+		return bytes.Equal(pub, certPub), nil
+	default:
+		return false, fmt.Errorf("unsupported private key type %T", priv)
+	}
+}
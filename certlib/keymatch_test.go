@@ -0,0 +1,68 @@
+package certlib
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+const (
+	testEd25519Cert = "testdata/ed25519-leaf.pem"
+	testEd25519Key  = "testdata/ed25519-leaf.key"
+)
+
+func TestMatchKeysEd25519(t *testing.T) {
+	cert, err := LoadCertificate(testEd25519Cert)
+	assert.NoErrorT(t, err)
+
+	priv, err := LoadPrivateKey(testEd25519Key)
+	assert.NoErrorT(t, err)
+
+	match, err := MatchKeys(cert, priv)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, match, "lib: expected the Ed25519 certificate and key to match")
+
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoErrorT(t, err)
+
+	match, err = MatchKeys(cert, other)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, !match, "lib: expected a different Ed25519 key not to match")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoErrorT(t, err)
+
+	match, err = MatchKeys(cert, ecKey)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, !match, "lib: expected an ECDSA key not to match an Ed25519 certificate")
+}
+
+func TestPublicKeyBytesEqual(t *testing.T) {
+	cert, err := LoadCertificate(testEd25519Cert)
+	assert.NoErrorT(t, err)
+
+	priv, err := LoadPrivateKey(testEd25519Key)
+	assert.NoErrorT(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoErrorT(t, err)
+
+	equal, err := PublicKeyBytesEqual(cert.PublicKey, priv.Public())
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, equal, "lib: expected the Ed25519 public keys to be equal")
+
+	equal, err = PublicKeyBytesEqual(ecKey.Public(), ecKey.Public())
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, equal, "lib: expected the ECDSA public keys to be equal")
+
+	equal, err = PublicKeyBytesEqual(cert.PublicKey, ecKey.Public())
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, !equal, "lib: expected Ed25519 and ECDSA public keys to differ")
+
+	_, err = PublicKeyBytesEqual(cert.PublicKey, "not a key")
+	assert.ErrorT(t, err)
+}
@@ -0,0 +1,58 @@
+package certlib
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"golang.org/x/term"
+)
+
+// readPassword reads a password from standard input. If standard
+// input is a terminal, the user is prompted and echo is disabled;
+// otherwise, a single line is read.
+func readPassword() ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Private key password: ")
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return password, err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		return nil, err
+	}
+
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// ParsePrivateKeyPEMWithPasswordPrompt parses and returns a
+// PEM-encoded private key. If the key is encrypted, the password is
+// read from standard input. The user is only prompted once; if the
+// password is wrong, an error is returned and it is up to the caller
+// to try again.
+func ParsePrivateKeyPEMWithPasswordPrompt(keyPEM []byte) (crypto.Signer, error) {
+	keyDER, err := GetKeyDERFromPEM(keyPEM, nil)
+	if errors.Is(err, certerr.ErrEncryptedPrivateKey) {
+		var password []byte
+		password, err = readPassword()
+		if err != nil {
+			return nil, certerr.DecodeError(certerr.ErrorSourcePrivateKey, err)
+		}
+
+		keyDER, err = GetKeyDERFromPEM(keyPEM, password)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePrivateKeyDER(keyDER)
+}
@@ -0,0 +1,62 @@
+package certlib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+func withStdin(t *testing.T, input string, f func()) {
+	r, w, err := os.Pipe()
+	assert.NoErrorT(t, err)
+
+	_, err = w.WriteString(input)
+	assert.NoErrorT(t, err)
+	w.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = stdin
+		r.Close()
+	}()
+
+	f()
+}
+
+func TestParsePrivateKeyPEMWithPasswordPrompt(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoErrorT(t, err)
+
+	der, err := x509.MarshalECPrivateKey(priv)
+	assert.NoErrorT(t, err)
+
+	block, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", der, []byte("hunter2"), x509.PEMCipherAES256)
+	assert.NoErrorT(t, err)
+	keyPEM := pem.EncodeToMemory(block)
+
+	withStdin(t, "hunter2\n", func() {
+		key, err := ParsePrivateKeyPEMWithPasswordPrompt(keyPEM)
+		assert.NoErrorT(t, err)
+		assert.BoolT(t, priv.Equal(key), "certlib: decrypted key doesn't match the original key")
+	})
+
+	withStdin(t, "hunter3\n", func() {
+		_, err := ParsePrivateKeyPEMWithPasswordPrompt(keyPEM)
+		assert.ErrorT(t, err, "certlib: expected an error with the wrong password")
+	})
+}
+
+func TestReadCertificateStdin(t *testing.T) {
+	withStdin(t, testCerts, func() {
+		certs, err := ReadCertificateStdin()
+		assert.NoErrorT(t, err)
+		assert.BoolT(t, len(certs) == 3, "certlib: expected three certificates from standard input")
+	})
+}
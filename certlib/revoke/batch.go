@@ -0,0 +1,65 @@
+package revoke
+
+import (
+	"context"
+	"crypto/x509"
+)
+
+// Result is the revocation status of a single certificate checked by
+// BatchVerify; Revoked, Ok, and Err are as returned by
+// VerifyCertificateError.
+type Result struct {
+	Cert    *x509.Certificate
+	Revoked bool
+	Ok      bool
+	Err     error
+}
+
+// issuerKey identifies the issuer of a certificate by its name and
+// key identifier.
+func issuerKey(cert *x509.Certificate) string {
+	return string(cert.RawIssuer) + "\x00" + string(cert.AuthorityKeyId)
+}
+
+// BatchVerify checks the revocation status of each certificate in
+// certs, returning the results in the same order. Certificates are
+// grouped by issuer so that each issuer certificate is fetched at
+// most once, and only if a certificate whose status isn't already in
+// the status cache needs it; a failed fetch isn't retried for the
+// rest of the group. CRLs are shared through CRLSet, so each is also
+// only fetched once. OCSP responses are per certificate, so one
+// request is still made for each certificate that has an OCSP server.
+func BatchVerify(certs []*x509.Certificate) []Result {
+	return BatchVerifyContext(context.Background(), certs)
+}
+
+// BatchVerifyContext is like BatchVerify, but the requests are made
+// with ctx, so they may be cancelled or given a deadline.
+func BatchVerifyContext(ctx context.Context, certs []*x509.Certificate) []Result {
+	var order []string
+	groups := map[string][]int{}
+	for i, cert := range certs {
+		key := issuerKey(cert)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	results := make([]Result, len(certs))
+	for _, key := range order {
+		issuer := &issuerLookup{}
+		for _, i := range groups[key] {
+			cert := certs[i]
+			revoked, ok, err := verifyCertificate(ctx, cert, issuer)
+			results[i] = Result{
+				Cert:    cert,
+				Revoked: revoked,
+				Ok:      ok,
+				Err:     err,
+			}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,156 @@
+package revoke
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestBatchVerify(t *testing.T) {
+	ca, key := issueTestCert(t, testCA("batch CA"), nil, nil)
+
+	var leaves []*x509.Certificate
+	for serial := int64(2); serial <= 3; serial++ {
+		leaf, _ := issueTestCert(t, testLeaf(serial), ca, key)
+		leaves = append(leaves, leaf)
+	}
+
+	// The second leaf is revoked.
+	statuses := map[int64]int{2: ocsp.Good, 3: ocsp.Revoked}
+
+	caFetches, ocspRequests := 0, 0
+	oldClient := HTTPClient
+	defer func() { HTTPClient = oldClient }()
+	HTTPClient = &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			respond := func(body []byte) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader(body)),
+					Request:    req,
+				}, nil
+			}
+
+			if strings.HasSuffix(req.URL.Path, "/ca.der") {
+				caFetches++
+				return respond(ca.Raw)
+			}
+
+			ocspRequests++
+			encoded, err := url.QueryUnescape(strings.TrimPrefix(req.URL.EscapedPath(), "/"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			raw, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ocspReq, err := ocsp.ParseRequest(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+				Status:       statuses[ocspReq.SerialNumber.Int64()],
+				SerialNumber: ocspReq.SerialNumber,
+				ThisUpdate:   time.Now().Add(-time.Minute),
+				NextUpdate:   time.Now().Add(time.Hour),
+				RevokedAt:    time.Now().Add(-time.Minute),
+			}, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return respond(resp)
+		}),
+	}
+
+	results := BatchVerify(leaves)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, have %d", len(results))
+	}
+
+	if results[0].Cert != leaves[0] || results[0].Revoked || !results[0].Ok || results[0].Err != nil {
+		t.Fatalf("expected the first certificate to be good, have %+v", results[0])
+	}
+
+	if results[1].Cert != leaves[1] || !results[1].Revoked || !results[1].Ok {
+		t.Fatalf("expected the second certificate to be revoked, have %+v", results[1])
+	}
+
+	if caFetches != 1 {
+		t.Fatalf("expected the issuer to be fetched once, have %d fetches", caFetches)
+	}
+
+	if ocspRequests != 2 {
+		t.Fatalf("expected one OCSP request per certificate, have %d", ocspRequests)
+	}
+}
+
+func TestBatchVerifyIssuerLookup(t *testing.T) {
+	ca, key := issueTestCert(t, testCA("batch CA"), nil, nil)
+
+	var leaves []*x509.Certificate
+	for serial := int64(2); serial <= 4; serial++ {
+		leaf, _ := issueTestCert(t, testLeaf(serial), ca, key)
+		leaves = append(leaves, leaf)
+	}
+
+	// The issuer can't be fetched, and no OCSP request can be made
+	// without it.
+	caFetches := 0
+	oldClient := HTTPClient
+	defer func() { HTTPClient = oldClient }()
+	HTTPClient = &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/ca.der") {
+				caFetches++
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       io.NopCloser(bytes.NewReader(nil)),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	for i, result := range BatchVerify(leaves) {
+		if result.Ok {
+			t.Fatalf("expected certificate %d's status to be unknown without its issuer, have %+v", i, result)
+		}
+	}
+
+	if caFetches != 1 {
+		t.Fatalf("expected a failed issuer fetch not to be retried, have %d fetches", caFetches)
+	}
+
+	// Statuses already in the status cache don't need the issuer.
+	cache := NewCache(0, 0)
+	SetGlobalCache(cache)
+	defer SetGlobalCache(nil)
+	for _, leaf := range leaves {
+		cache.put(leaf, false, 0, time.Now().Add(time.Hour))
+	}
+
+	caFetches = 0
+	for i, result := range BatchVerify(leaves) {
+		if !result.Ok || result.Revoked {
+			t.Fatalf("expected certificate %d's cached good status, have %+v", i, result)
+		}
+	}
+
+	if caFetches != 0 {
+		t.Fatalf("expected cached statuses not to fetch the issuer, have %d fetches", caFetches)
+	}
+}
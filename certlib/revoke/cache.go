@@ -0,0 +1,216 @@
+package revoke
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/fileutil"
+	"git.wntrmute.dev/kyle/goutils/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+// OCSPCache, if not nil, is consulted before sending an OCSP request,
+// and successful OCSP responses are stored in it. It sits below the
+// status cache set by SetGlobalCache: that cache holds the combined
+// result of the CRL and OCSP checks for the life of the process, and
+// this one holds only OCSP responses, which may be kept on disk
+// between runs.
+var OCSPCache *OCSPResponseCache
+
+// ocspCacheEntry records the parts of an OCSP response needed to
+// answer a revocation check.
+type ocspCacheEntry struct {
+	Issuer     string    `json:"issuer"`
+	Serial     string    `json:"serial"`
+	Status     string    `json:"status"`
+	Reason     int       `json:"reason,omitempty"`
+	ThisUpdate time.Time `json:"this_update"`
+	NextUpdate time.Time `json:"next_update"`
+}
+
+var ocspStatuses = map[int]string{
+	ocsp.Good:    "good",
+	ocsp.Revoked: "revoked",
+	ocsp.Unknown: "unknown",
+}
+
+// OCSPResponseCache stores the status of OCSP responses until their
+// next update time. If it has a path, it is written to disk as JSON
+// whenever an entry is added.
+type OCSPResponseCache struct {
+	lock       sync.Mutex
+	path       string
+	maxEntries int
+	entries    map[string]*ocspCacheEntry
+}
+
+// NewOCSPCache returns an in-memory OCSP response cache holding at
+// most maxEntries responses. If maxEntries is zero, the cache isn't
+// limited.
+func NewOCSPCache(maxEntries int) *OCSPResponseCache {
+	return &OCSPResponseCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*ocspCacheEntry{},
+	}
+}
+
+// NewPersistentOCSPCache returns an OCSP response cache that is
+// stored at path. If the file exists, the cache is loaded from it; a
+// file that can't be parsed, such as one left truncated by an older
+// version, is logged and treated as an empty cache, which replaces it
+// on the next write.
+func NewPersistentOCSPCache(path string, maxEntries int) (*OCSPResponseCache, error) {
+	cache := NewOCSPCache(maxEntries)
+	cache.path = path
+
+	in, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cache, nil
+		}
+		return nil, err
+	}
+
+	var entries []*ocspCacheEntry
+	if err = json.Unmarshal(in, &entries); err != nil {
+		log.Warningf("ignoring invalid OCSP cache %s: %v", path, err)
+		return cache, nil
+	}
+
+	for _, entry := range entries {
+		cache.entries[entry.Issuer+":"+entry.Serial] = entry
+	}
+	cache.evict()
+
+	return cache, nil
+}
+
+func ocspCacheKey(cert *x509.Certificate) (issuer, serial string) {
+	issuerHash := sha256.Sum256(cert.RawIssuer)
+	return hex.EncodeToString(issuerHash[:]), cert.SerialNumber.Text(16)
+}
+
+// evict removes expired entries, and then removes the entries that
+// expire soonest until the cache is back under its size limit. The
+// caller must hold the lock.
+func (cache *OCSPResponseCache) evict() {
+	now := time.Now()
+	for key, entry := range cache.entries {
+		if !now.Before(entry.NextUpdate) {
+			delete(cache.entries, key)
+		}
+	}
+
+	for cache.maxEntries > 0 && len(cache.entries) > cache.maxEntries {
+		var oldest string
+		for key, entry := range cache.entries {
+			if oldest == "" || entry.NextUpdate.Before(cache.entries[oldest].NextUpdate) {
+				oldest = key
+			}
+		}
+		delete(cache.entries, oldest)
+	}
+}
+
+// Get returns the cached OCSP status (e.g. ocsp.Good) for cert. The
+// boolean is false if there is no valid entry for the certificate.
+func (cache *OCSPResponseCache) Get(cert *x509.Certificate) (int, bool) {
+	entry, ok := cache.lookup(cert)
+	if !ok {
+		return 0, false
+	}
+
+	for status, name := range ocspStatuses {
+		if name == entry.Status {
+			return status, true
+		}
+	}
+
+	return 0, false
+}
+
+// lookup returns a copy of the unexpired entry for cert, if there is
+// one.
+func (cache *OCSPResponseCache) lookup(cert *x509.Certificate) (ocspCacheEntry, bool) {
+	issuer, serial := ocspCacheKey(cert)
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	entry, ok := cache.entries[issuer+":"+serial]
+	if !ok || !time.Now().Before(entry.NextUpdate) {
+		return ocspCacheEntry{}, false
+	}
+
+	return *entry, true
+}
+
+// Put stores the OCSP response for cert. Responses without a next
+// update time can't be cached, and are ignored. If the cache is
+// persistent, it is written to disk.
+func (cache *OCSPResponseCache) Put(cert *x509.Certificate, resp *ocsp.Response) error {
+	if resp.NextUpdate.IsZero() {
+		return nil
+	}
+
+	status, ok := ocspStatuses[resp.Status]
+	if !ok {
+		return fmt.Errorf("revoke: invalid OCSP status %d", resp.Status)
+	}
+
+	var reason int
+	if resp.Status == ocsp.Revoked {
+		reason = resp.RevocationReason
+	}
+
+	issuer, serial := ocspCacheKey(cert)
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.entries[issuer+":"+serial] = &ocspCacheEntry{
+		Issuer:     issuer,
+		Serial:     serial,
+		Status:     status,
+		Reason:     reason,
+		ThisUpdate: resp.ThisUpdate,
+		NextUpdate: resp.NextUpdate,
+	}
+	cache.evict()
+
+	return cache.flush()
+}
+
+// Flush writes the cache to disk. It does nothing if the cache isn't
+// persistent.
+func (cache *OCSPResponseCache) Flush() error {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	return cache.flush()
+}
+
+func (cache *OCSPResponseCache) flush() error {
+	if cache.path == "" {
+		return nil
+	}
+
+	entries := make([]*ocspCacheEntry, 0, len(cache.entries))
+	for _, entry := range cache.entries {
+		entries = append(entries, entry)
+	}
+
+	out, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return fileutil.AtomicWriteFile(cache.path, out, 0644)
+}
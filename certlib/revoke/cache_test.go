@@ -0,0 +1,99 @@
+package revoke
+
+import (
+	"crypto/x509"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestPersistentOCSPCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ocsp.json")
+	cache, err := NewPersistentOCSPCache(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	good := &x509.Certificate{RawIssuer: []byte("issuer"), SerialNumber: big.NewInt(1)}
+	revoked := &x509.Certificate{RawIssuer: []byte("issuer"), SerialNumber: big.NewInt(2)}
+	other := &x509.Certificate{RawIssuer: []byte("other issuer"), SerialNumber: big.NewInt(1)}
+
+	err = cache.Put(good, &ocsp.Response{Status: ocsp.Good, ThisUpdate: now, NextUpdate: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cache.Put(revoked, &ocsp.Response{
+		Status:           ocsp.Revoked,
+		RevocationReason: ocsp.KeyCompromise,
+		ThisUpdate:       now,
+		NextUpdate:       now.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err = NewPersistentOCSPCache(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status, ok := cache.Get(good); !ok || status != ocsp.Good {
+		t.Fatalf("revoke: expected cached good status, have %d (cached: %v)", status, ok)
+	}
+
+	if status, ok := cache.Get(revoked); !ok || status != ocsp.Revoked {
+		t.Fatalf("revoke: expected cached revoked status, have %d (cached: %v)", status, ok)
+	}
+
+	if entry, ok := cache.lookup(revoked); !ok || entry.Reason != ocsp.KeyCompromise {
+		t.Fatalf("revoke: expected the revocation reason to be cached, have %d", entry.Reason)
+	}
+
+	if _, ok := cache.Get(other); ok {
+		t.Fatal("revoke: certificates from different issuers shouldn't share a cache entry")
+	}
+
+	// Adding a third entry should evict the one expiring soonest.
+	err = cache.Put(other, &ocsp.Response{Status: ocsp.Good, ThisUpdate: now, NextUpdate: now.Add(3 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cache.Get(good); ok {
+		t.Fatal("revoke: expected the oldest entry to be evicted")
+	}
+}
+
+func TestPersistentOCSPCacheInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ocsp.json")
+	if err := os.WriteFile(path, []byte(`[{"issuer": "trunc`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewPersistentOCSPCache(path, 0)
+	if err != nil {
+		t.Fatalf("revoke: expected an invalid cache to be treated as empty: %v", err)
+	}
+
+	now := time.Now()
+	cert := &x509.Certificate{RawIssuer: []byte("issuer"), SerialNumber: big.NewInt(1)}
+	err = cache.Put(cert, &ocsp.Response{Status: ocsp.Good, ThisUpdate: now, NextUpdate: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err = NewPersistentOCSPCache(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status, ok := cache.Get(cert); !ok || status != ocsp.Good {
+		t.Fatalf("revoke: expected the rewritten cache to hold the new entry, have %d (cached: %v)", status, ok)
+	}
+}
@@ -5,6 +5,7 @@ package revoke
 
 import (
 	"bytes"
+	"context"
 	"crypto"
 	"crypto/x509"
 	"encoding/base64"
@@ -18,6 +19,7 @@ import (
 	"time"
 
 	"git.wntrmute.dev/kyle/goutils/certlib"
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
 	"git.wntrmute.dev/kyle/goutils/log"
 	"golang.org/x/crypto/ocsp"
 )
@@ -67,6 +69,62 @@ var HardFail = false
 var CRLSet = map[string]*x509.RevocationList{}
 var crlLock = new(sync.Mutex)
 
+// RevocationError is returned by VerifyCertificateError, wrapped in a
+// certerr.Error, when a certificate is treated as revoked. Confirmed
+// is true if a CRL or OCSP response said the certificate was revoked,
+// and false if the revocation check couldn't be completed and HardFail
+// is set. Expired is true if the certificate was treated as revoked
+// because it is outside its validity period; Confirmed is also set in
+// that case.
+type RevocationError struct {
+	Confirmed bool
+	Expired   bool
+
+	// Reason is the RFC 5280 reason code (e.g. ocsp.KeyCompromise)
+	// given by the CRL or OCSP response that revoked the
+	// certificate.
+	Reason int
+
+	// Err is the reason the check couldn't be completed, or
+	// why the certificate isn't valid, if there is one.
+	Err error
+}
+
+func (err *RevocationError) Error() string {
+	if err.Expired && err.Err != nil {
+		return err.Err.Error()
+	}
+
+	if err.Confirmed {
+		if name, ok := reasonNames[err.Reason]; ok && err.Reason != ocsp.Unspecified {
+			return "certificate has been revoked: " + name
+		}
+		return "certificate has been revoked"
+	}
+
+	if err.Err == nil {
+		return "revocation status could not be determined"
+	}
+	return fmt.Sprintf("revocation status could not be determined: %v", err.Err)
+}
+
+func (err *RevocationError) Unwrap() error {
+	return err.Err
+}
+
+var reasonNames = map[int]string{
+	ocsp.Unspecified:          "unspecified",
+	ocsp.KeyCompromise:        "key compromise",
+	ocsp.CACompromise:         "CA compromise",
+	ocsp.AffiliationChanged:   "affiliation changed",
+	ocsp.Superseded:           "superseded",
+	ocsp.CessationOfOperation: "cessation of operation",
+	ocsp.CertificateHold:      "certificate hold",
+	ocsp.RemoveFromCRL:        "remove from CRL",
+	ocsp.PrivilegeWithdrawn:   "privilege withdrawn",
+	ocsp.AACompromise:         "AA compromise",
+}
+
 // We can't handle LDAP certificates, so this checks to see if the
 // URL string points to an LDAP resource so that we can ignore it.
 func ldapURL(url string) bool {
@@ -90,42 +148,59 @@ func ldapURL(url string) bool {
 // - false, true:  the certificate was checked successfully, and it is not revoked.
 // - true, true:   the certificate was checked successfully, and it is revoked.
 // - true, false:  failure to check revocation status causes verification to fail
-func revCheck(cert *x509.Certificate) (revoked, ok bool, err error) {
+//
+// If the certificate is revoked, reason is the reason code given by
+// the CRL or OCSP response. If the status came from an OCSP response,
+// nextUpdate is the time at which the responder will have newer
+// information. The issuer is only fetched, through issuer, if it is
+// needed.
+func revCheck(ctx context.Context, cert *x509.Certificate, issuer *issuerLookup) (revoked, ok bool, reason int, nextUpdate time.Time, err error) {
 	for _, url := range cert.CRLDistributionPoints {
 		if ldapURL(url) {
 			log.Infof("skipping LDAP CRL: %s", url)
 			continue
 		}
 
-		if revoked, ok, err := certIsRevokedCRL(cert, url); !ok {
+		if revoked, ok, reason, err := certIsRevokedCRL(ctx, cert, issuer, url); !ok {
 			log.Warning("error checking revocation via CRL")
 			if HardFail {
-				return true, false, err
+				return true, false, 0, nextUpdate, err
 			}
-			return false, false, err
+			return false, false, 0, nextUpdate, err
 		} else if revoked {
 			log.Info("certificate is revoked via CRL")
-			return true, true, err
+			return true, true, reason, nextUpdate, err
 		}
 	}
 
-	if revoked, ok, err := certIsRevokedOCSP(cert, HardFail); !ok {
+	revoked, ok, reason, nextUpdate, err = ocspStatus(ctx, cert, issuer, HardFail)
+	if !ok {
 		log.Warning("error checking revocation via OCSP")
 		if HardFail {
-			return true, false, err
+			return true, false, 0, nextUpdate, err
 		}
-		return false, false, err
+		return false, false, 0, nextUpdate, err
 	} else if revoked {
 		log.Info("certificate is revoked via OCSP")
-		return true, true, err
+		return true, true, reason, nextUpdate, err
 	}
 
-	return false, true, nil
+	return false, true, 0, nextUpdate, nil
+}
+
+// httpGet issues a GET request for url using HTTPClient.
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return HTTPClient.Do(req)
 }
 
 // fetchCRL fetches and parses a CRL.
-func fetchCRL(url string) (*x509.RevocationList, error) {
-	resp, err := HTTPClient.Get(url)
+func fetchCRL(ctx context.Context, url string) (*x509.RevocationList, error) {
+	resp, err := httpGet(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -142,11 +217,37 @@ func fetchCRL(url string) (*x509.RevocationList, error) {
 	return x509.ParseRevocationList(body)
 }
 
-func getIssuer(cert *x509.Certificate) *x509.Certificate {
+// issuerLookup fetches a certificate's issuer the first time it is
+// needed, and remembers the result, including a failed fetch, so that
+// it is fetched at most once. BatchVerify shares one between the
+// certificates with the same issuer. A nil *issuerLookup fetches the
+// issuer every time.
+type issuerLookup struct {
+	issuer  *x509.Certificate
+	fetched bool
+}
+
+func (l *issuerLookup) get(ctx context.Context, cert *x509.Certificate) *x509.Certificate {
+	if l == nil {
+		return getIssuer(ctx, cert)
+	}
+
+	// A certificate without issuing certificate URLs says nothing
+	// about whether the issuer can be fetched, so another
+	// certificate may still try.
+	if !l.fetched && len(cert.IssuingCertificateURL) > 0 {
+		l.issuer = getIssuer(ctx, cert)
+		l.fetched = true
+	}
+
+	return l.issuer
+}
+
+func getIssuer(ctx context.Context, cert *x509.Certificate) *x509.Certificate {
 	var issuer *x509.Certificate
 	var err error
 	for _, issuingCert := range cert.IssuingCertificateURL {
-		issuer, err = fetchRemote(issuingCert)
+		issuer, err = fetchRemote(ctx, issuingCert)
 		if err != nil {
 			continue
 		}
@@ -158,8 +259,10 @@ func getIssuer(cert *x509.Certificate) *x509.Certificate {
 }
 
 // check a cert against a specific CRL. Returns the same bool pair
-// as revCheck, plus an error if one occurred.
-func certIsRevokedCRL(cert *x509.Certificate, url string) (revoked, ok bool, err error) {
+// as revCheck, the CRL entry's reason code if the certificate is
+// revoked, and an error if one occurred. If the CRL has to be fetched,
+// the issuer is fetched through issuer to check the CRL's signature.
+func certIsRevokedCRL(ctx context.Context, cert *x509.Certificate, issuer *issuerLookup, url string) (revoked, ok bool, reason int, err error) {
 	crlLock.Lock()
 	crl, ok := CRLSet[url]
 	if ok && crl == nil {
@@ -175,22 +278,20 @@ func certIsRevokedCRL(cert *x509.Certificate, url string) (revoked, ok bool, err
 		}
 	}
 
-	issuer := getIssuer(cert)
-
 	if shouldFetchCRL {
 		var err error
-		crl, err = fetchCRL(url)
+		crl, err = fetchCRL(ctx, url)
 		if err != nil {
 			log.Warningf("failed to fetch CRL: %v", err)
-			return false, false, err
+			return false, false, 0, err
 		}
 
 		// check CRL signature
-		if issuer != nil {
-			err = crl.CheckSignatureFrom(issuer)
+		if issuerCert := issuer.get(ctx, cert); issuerCert != nil {
+			err = crl.CheckSignatureFrom(issuerCert)
 			if err != nil {
 				log.Warningf("failed to verify CRL: %v", err)
-				return false, false, err
+				return false, false, 0, err
 			}
 		}
 
@@ -199,14 +300,23 @@ func certIsRevokedCRL(cert *x509.Certificate, url string) (revoked, ok bool, err
 		crlLock.Unlock()
 	}
 
+	for _, entry := range crl.RevokedCertificateEntries {
+		if cert.SerialNumber.Cmp(entry.SerialNumber) == 0 {
+			log.Info("Serial number match: intermediate is revoked.")
+			return true, true, entry.ReasonCode, err
+		}
+	}
+
+	// CRLs that weren't parsed, such as those added to CRLSet
+	// by hand, may only list their revoked certificates here.
 	for _, revoked := range crl.RevokedCertificates {
 		if cert.SerialNumber.Cmp(revoked.SerialNumber) == 0 {
 			log.Info("Serial number match: intermediate is revoked.")
-			return true, true, err
+			return true, true, 0, err
 		}
 	}
 
-	return false, true, err
+	return false, true, 0, err
 }
 
 // VerifyCertificate ensures that the certificate passed in hasn't
@@ -217,22 +327,77 @@ func VerifyCertificate(cert *x509.Certificate) (revoked, ok bool) {
 }
 
 // VerifyCertificateError ensures that the certificate passed in hasn't
-// expired and checks the CRL for the server.
+// expired and checks the CRL for the server. If the certificate is
+// treated as revoked, the error wraps a *RevocationError.
 func VerifyCertificateError(cert *x509.Certificate) (revoked, ok bool, err error) {
+	return VerifyCertificateErrorContext(context.Background(), cert)
+}
+
+// VerifyCertificateErrorContext is like VerifyCertificateError, but
+// the CRL and OCSP requests are made with ctx, so they may be
+// cancelled or given a deadline.
+func VerifyCertificateErrorContext(ctx context.Context, cert *x509.Certificate) (revoked, ok bool, err error) {
+	return verifyCertificate(ctx, cert, &issuerLookup{})
+}
+
+// verifyCertificate implements VerifyCertificateErrorContext. The
+// certificate's issuer is fetched through issuer, and only if a CRL
+// or OCSP request needs it.
+func verifyCertificate(ctx context.Context, cert *x509.Certificate, issuer *issuerLookup) (revoked, ok bool, err error) {
 	if !time.Now().Before(cert.NotAfter) {
-		msg := fmt.Sprintf("Certificate expired %s\n", cert.NotAfter)
+		msg := fmt.Sprintf("Certificate expired %s", cert.NotAfter)
 		log.Info(msg)
-		return true, true, fmt.Errorf(msg)
+		return true, true, expiredError(msg)
 	} else if !time.Now().After(cert.NotBefore) {
-		msg := fmt.Sprintf("Certificate isn't valid until %s\n", cert.NotBefore)
+		msg := fmt.Sprintf("Certificate isn't valid until %s", cert.NotBefore)
 		log.Info(msg)
-		return true, true, fmt.Errorf(msg)
+		return true, true, expiredError(msg)
+	}
+
+	cache := globalCache.Load()
+	if cache != nil {
+		if cachedRevoked, reason, cached := cache.get(cert); cached {
+			revoked, ok = cachedRevoked, true
+			if revoked {
+				err = certerr.VerifyError(certerr.ErrorSourceCertificate, &RevocationError{
+					Confirmed: true,
+					Reason:    reason,
+				})
+			}
+			return revoked, ok, err
+		}
+	}
+
+	var reason int
+	var nextUpdate time.Time
+	revoked, ok, reason, nextUpdate, err = revCheck(ctx, cert, issuer)
+	if cache != nil && ok {
+		cache.put(cert, revoked, reason, nextUpdate)
+	}
+
+	if revoked {
+		err = certerr.VerifyError(certerr.ErrorSourceCertificate, &RevocationError{
+			Confirmed: ok,
+			Reason:    reason,
+			Err:       err,
+		})
 	}
-	return revCheck(cert)
+
+	return revoked, ok, err
+}
+
+// expiredError returns the error for a certificate that is treated
+// as revoked because it is outside its validity period.
+func expiredError(msg string) error {
+	return certerr.VerifyError(certerr.ErrorSourceCertificate, &RevocationError{
+		Confirmed: true,
+		Expired:   true,
+		Err:       errors.New(msg),
+	})
 }
 
-func fetchRemote(url string) (*x509.Certificate, error) {
-	resp, err := HTTPClient.Get(url)
+func fetchRemote(ctx context.Context, url string) (*x509.Certificate, error) {
+	resp, err := httpGet(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -255,60 +420,88 @@ var ocspOpts = ocsp.RequestOptions{
 	Hash: crypto.SHA1,
 }
 
-func certIsRevokedOCSP(leaf *x509.Certificate, strict bool) (revoked, ok bool, e error) {
+func certIsRevokedOCSP(ctx context.Context, leaf *x509.Certificate, strict bool) (revoked, ok bool, e error) {
+	revoked, ok, _, _, e = ocspStatus(ctx, leaf, nil, strict)
+	return revoked, ok, e
+}
+
+// ocspStatus is certIsRevokedOCSP, but it also returns the revocation
+// reason and the next update time from the OCSP response, if one was
+// fetched. The issuer is fetched through issuer if the response isn't
+// cached.
+func ocspStatus(ctx context.Context, leaf *x509.Certificate, issuer *issuerLookup, strict bool) (revoked, ok bool, reason int, nextUpdate time.Time, e error) {
 	var err error
 
 	ocspURLs := leaf.OCSPServer
 	if len(ocspURLs) == 0 {
 		// OCSP not enabled for this certificate.
-		return false, true, nil
+		return false, true, 0, nextUpdate, nil
 	}
 
-	issuer := getIssuer(leaf)
+	if OCSPCache != nil {
+		if entry, cached := OCSPCache.lookup(leaf); cached {
+			return entry.Status != ocspStatuses[ocsp.Good], true, entry.Reason, entry.NextUpdate, nil
+		}
+	}
 
-	if issuer == nil {
-		return false, false, nil
+	issuerCert := issuer.get(ctx, leaf)
+	if issuerCert == nil {
+		return false, false, 0, nextUpdate, nil
 	}
 
-	ocspRequest, err := ocsp.CreateRequest(leaf, issuer, &ocspOpts)
+	ocspRequest, err := ocsp.CreateRequest(leaf, issuerCert, &ocspOpts)
 	if err != nil {
-		return revoked, ok, err
+		return revoked, ok, reason, nextUpdate, err
 	}
 
 	for _, server := range ocspURLs {
-		resp, err := sendOCSPRequest(server, ocspRequest, leaf, issuer)
+		resp, err := sendOCSPRequest(ctx, server, ocspRequest, leaf, issuerCert)
 		if err != nil {
 			if strict {
-				return revoked, ok, err
+				return revoked, ok, reason, nextUpdate, err
 			}
 			continue
 		}
 
 		// There wasn't an error fetching the OCSP status.
 		ok = true
+		nextUpdate = resp.NextUpdate
+
+		if OCSPCache != nil {
+			if err := OCSPCache.Put(leaf, resp); err != nil {
+				log.Warningf("failed to cache OCSP response: %v", err)
+			}
+		}
 
 		if resp.Status != ocsp.Good {
 			// The certificate was revoked.
 			revoked = true
+			reason = resp.RevocationReason
 		}
 
-		return revoked, ok, err
+		return revoked, ok, reason, nextUpdate, err
 	}
-	return revoked, ok, err
+	return revoked, ok, reason, nextUpdate, err
 }
 
 // sendOCSPRequest attempts to request an OCSP response from the
 // server. The error only indicates a failure to *fetch* the
 // certificate, and *does not* mean the certificate is valid.
-func sendOCSPRequest(server string, req []byte, leaf, issuer *x509.Certificate) (*ocsp.Response, error) {
+func sendOCSPRequest(ctx context.Context, server string, req []byte, leaf, issuer *x509.Certificate) (*ocsp.Response, error) {
 	var resp *http.Response
 	var err error
 	if len(req) > 256 {
-		buf := bytes.NewBuffer(req)
-		resp, err = HTTPClient.Post(server, "application/ocsp-request", buf)
+		var httpReq *http.Request
+		httpReq, err = http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewBuffer(req))
+		if err != nil {
+			return nil, err
+		}
+
+		httpReq.Header.Set("Content-Type", "application/ocsp-request")
+		resp, err = HTTPClient.Do(httpReq)
 	} else {
 		reqURL := server + "/" + neturl.QueryEscape(base64.StdEncoding.EncodeToString(req))
-		resp, err = HTTPClient.Get(reqURL)
+		resp, err = httpGet(ctx, reqURL)
 	}
 
 	if err != nil {
@@ -1,9 +1,16 @@
 package revoke
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"testing"
 	"time"
@@ -160,6 +167,66 @@ func mustParse(pemData string) *x509.Certificate {
 	return cert
 }
 
+// issueTestCert issues a certificate from tmpl for a new key, signed
+// by issuerKey under issuer, and returns it with its key. If issuer is
+// nil, the certificate is self-signed. A missing serial number or
+// validity period is filled in.
+func issueTestCert(t *testing.T, tmpl, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tmpl.SerialNumber == nil {
+		tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	}
+
+	if tmpl.NotBefore.IsZero() {
+		tmpl.NotBefore = time.Now().Add(-time.Hour)
+	}
+
+	if tmpl.NotAfter.IsZero() {
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+	}
+
+	if issuer == nil {
+		issuer, issuerKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// testCA returns a template for a CA certificate for name.
+func testCA(name string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+}
+
+// testLeaf returns a template for a leaf certificate with the given
+// serial number that can be checked with OCSP, fetching its issuer
+// from ca.example.net.
+func testLeaf(serial int64) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "leaf.example.net"},
+		OCSPServer:            []string{"http://ocsp.example.net"},
+		IssuingCertificateURL: []string{"http://ca.example.net/ca.der"},
+	}
+}
+
 func TestRevoked(t *testing.T) {
 	if revoked, ok := VerifyCertificate(revokedCert); !ok {
 		fmt.Fprintf(os.Stderr, "Warning: soft fail checking revocation")
@@ -225,7 +292,7 @@ func TestBadCRLSet(t *testing.T) {
 	ldapCert := mustParse(goodComodoCA)
 	ldapCert.CRLDistributionPoints[0] = ""
 	CRLSet[""] = nil
-	certIsRevokedCRL(ldapCert, "")
+	certIsRevokedCRL(context.Background(), ldapCert, nil, "")
 	if _, ok := CRLSet[""]; ok {
 		t.Fatalf("key emptystring should be deleted from CRLSet")
 	}
@@ -244,7 +311,7 @@ func TestRemoteFetchError(t *testing.T) {
 
 	badurl := ":"
 
-	if _, err := fetchRemote(badurl); err == nil {
+	if _, err := fetchRemote(context.Background(), badurl); err == nil {
 		t.Fatalf("fetching bad url should result in non-nil error")
 	}
 
@@ -253,10 +320,53 @@ func TestRemoteFetchError(t *testing.T) {
 func TestNoOCSPServers(t *testing.T) {
 	badIssuer := goodCert
 	badIssuer.IssuingCertificateURL = []string{" "}
-	certIsRevokedOCSP(badIssuer, true)
+	certIsRevokedOCSP(context.Background(), badIssuer, true)
 	noOCSPCert := goodCert
 	noOCSPCert.OCSPServer = make([]string, 0)
-	if revoked, ok, _ := certIsRevokedOCSP(noOCSPCert, true); revoked || !ok {
+	if revoked, ok, _ := certIsRevokedOCSP(context.Background(), noOCSPCert, true); revoked || !ok {
 		t.Fatalf("OCSP falsely registered as enabled for this certificate")
 	}
 }
+
+func TestFetchCRLCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := fetchCRL(ctx, "http://127.0.0.1/crl"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("fetching a CRL with a cancelled context should fail with context.Canceled, have %v", err)
+	}
+}
+
+func TestRevocationError(t *testing.T) {
+	var revErr *RevocationError
+	revoked, ok, err := VerifyCertificateError(expiredCert)
+	if !revoked || !ok || !errors.As(err, &revErr) || !revErr.Confirmed || !revErr.Expired {
+		t.Fatalf("expired certificate should have been an expired revocation, have %v", err)
+	}
+
+	cert := mustParse(goodComodoCA)
+	cert.IssuingCertificateURL = nil
+	cert.OCSPServer = nil
+	cert.CRLDistributionPoints = []string{"http://crl.example.net/revoked.crl"}
+
+	crlLock.Lock()
+	CRLSet[cert.CRLDistributionPoints[0]] = &x509.RevocationList{
+		ThisUpdate:          time.Now().Add(-time.Hour),
+		RevokedCertificates: []pkix.RevokedCertificate{{SerialNumber: cert.SerialNumber}},
+	}
+	crlLock.Unlock()
+	defer delete(CRLSet, cert.CRLDistributionPoints[0])
+
+	revoked, ok, err = VerifyCertificateError(cert)
+	if !revoked || !ok || !errors.As(err, &revErr) || !revErr.Confirmed || revErr.Expired {
+		t.Fatalf("certificate should have been confirmed as revoked, have %v", err)
+	}
+
+	cert.CRLDistributionPoints = []string{""}
+	HardFail = true
+	defer func() { HardFail = false }()
+	revoked, ok, err = VerifyCertificateError(cert)
+	if !revoked || ok || !errors.As(err, &revErr) || revErr.Confirmed {
+		t.Fatalf("hard failure should have been an unconfirmed revocation, have %v", err)
+	}
+}
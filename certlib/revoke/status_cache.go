@@ -0,0 +1,127 @@
+package revoke
+
+import (
+	"container/list"
+	"crypto/x509"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCacheTTL is how long a Cache keeps a revocation status when
+// there is no OCSP next update time to go by.
+const DefaultCacheTTL = time.Hour
+
+var globalCache atomic.Pointer[Cache]
+
+// SetGlobalCache sets the cache consulted by VerifyCertificate and
+// friends before any CRL or OCSP requests are made; a nil cache
+// disables caching. Tools that check many certificates in a single
+// run, such as certverify with several targets, avoid repeating
+// network requests for certificates that have already been checked.
+func SetGlobalCache(c *Cache) {
+	globalCache.Store(c)
+}
+
+type statusCacheEntry struct {
+	key     string
+	revoked bool
+	reason  int
+	expires time.Time
+}
+
+// Cache is an in-memory LRU cache of revocation statuses, keyed on
+// the certificate's issuer and serial number. Only successful checks
+// are cached. Entries expire at the OCSP response's next update time
+// if there was one, and after the cache's TTL otherwise.
+//
+// A Cache sits above OCSPCache: it is checked before any CRL or OCSP
+// lookup and records the outcome of both, including the revocation
+// reason. OCSPCache is only consulted on a miss here, when an OCSP
+// request would otherwise be sent.
+type Cache struct {
+	lock       sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // most recently used at the front
+	entries    map[string]*list.Element
+}
+
+// NewCache returns a revocation status cache holding at most
+// maxEntries statuses; if maxEntries is zero, the cache isn't
+// limited. If ttl is zero, DefaultCacheTTL is used.
+func NewCache(maxEntries int, ttl time.Duration) *Cache {
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+func statusCacheKey(cert *x509.Certificate) string {
+	issuer, serial := ocspCacheKey(cert)
+	return issuer + ":" + serial
+}
+
+// Len returns the number of statuses in the cache, including any
+// that have expired but haven't been evicted yet.
+func (c *Cache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache) get(cert *x509.Certificate) (revoked bool, reason int, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elt, ok := c.entries[statusCacheKey(cert)]
+	if !ok {
+		return false, 0, false
+	}
+
+	entry := elt.Value.(*statusCacheEntry)
+	if !time.Now().Before(entry.expires) {
+		c.order.Remove(elt)
+		delete(c.entries, entry.key)
+		return false, 0, false
+	}
+
+	c.order.MoveToFront(elt)
+	return entry.revoked, entry.reason, true
+}
+
+func (c *Cache) put(cert *x509.Certificate, revoked bool, reason int, nextUpdate time.Time) {
+	expires := nextUpdate
+	if expires.IsZero() {
+		expires = time.Now().Add(c.ttl)
+	}
+
+	entry := &statusCacheEntry{
+		key:     statusCacheKey(cert),
+		revoked: revoked,
+		reason:  reason,
+		expires: expires,
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elt, ok := c.entries[entry.key]; ok {
+		elt.Value = entry
+		c.order.MoveToFront(elt)
+		return
+	}
+
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*statusCacheEntry).key)
+	}
+}
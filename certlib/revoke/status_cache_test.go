@@ -0,0 +1,97 @@
+package revoke
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCache(t *testing.T) {
+	ca, key := issueTestCert(t, testCA("test CA"), nil, nil)
+	leaf, _ := issueTestCert(t, testLeaf(2), ca, key)
+
+	ocspResp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: leaf.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := 0
+	oldClient := HTTPClient
+	defer func() { HTTPClient = oldClient }()
+	HTTPClient = &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			body := ocspResp
+			if strings.HasSuffix(req.URL.Path, "/ca.der") {
+				body = ca.Raw
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(body)),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	cache := NewCache(1, 0)
+	SetGlobalCache(cache)
+	defer SetGlobalCache(nil)
+
+	for i := 0; i < 2; i++ {
+		revoked, ok, err := VerifyCertificateError(leaf)
+		if revoked || !ok || err != nil {
+			t.Fatalf("expected the certificate to be good, have revoked=%v, ok=%v, err=%v", revoked, ok, err)
+		}
+	}
+
+	// The first check fetches the issuer and the OCSP response;
+	// the second should come from the cache.
+	if requests != 2 {
+		t.Fatalf("expected 2 network requests, have %d", requests)
+	}
+
+	other := &x509.Certificate{
+		RawIssuer:    []byte("issuer"),
+		SerialNumber: big.NewInt(3),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cache.put(other, true, ocsp.KeyCompromise, time.Time{})
+	if cache.Len() != 1 {
+		t.Fatalf("expected the cache to hold one status, have %d", cache.Len())
+	}
+
+	if _, _, ok := cache.get(leaf); ok {
+		t.Fatal("expected the least recently used status to be evicted")
+	}
+
+	if revoked, reason, ok := cache.get(other); !revoked || reason != ocsp.KeyCompromise || !ok {
+		t.Fatal("expected the revoked status and its reason to be cached")
+	}
+
+	var revErr *RevocationError
+	revoked, ok, err := VerifyCertificateError(other)
+	if !revoked || !ok || !errors.As(err, &revErr) || revErr.Reason != ocsp.KeyCompromise {
+		t.Fatalf("expected a cached revocation to keep its reason, have %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package certlib
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"sort"
+)
+
+func sortedExtKeyUsage(usages []x509.ExtKeyUsage) []x509.ExtKeyUsage {
+	sorted := make([]x509.ExtKeyUsage, len(usages))
+	copy(sorted, usages)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func sameExtKeyUsage(a, b []x509.ExtKeyUsage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = sortedExtKeyUsage(a)
+	b = sortedExtKeyUsage(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RotateCertificate checks that next is a valid renewal of old: the
+// subject and extended key usages must be the same, and the validity
+// period must not move backwards. If newKey is false, the public key
+// algorithm must be the same, though the key itself may be reused or
+// replaced. If newKey is true, next is a re-key: its public key must
+// differ from the old one, and may use a different algorithm. It
+// returns a description of each violation; an empty slice means the
+// rotation is acceptable.
+func RotateCertificate(old, next *x509.Certificate, newKey bool) []string {
+	var violations []string
+
+	if !bytes.Equal(old.RawSubject, next.RawSubject) {
+		violations = append(violations,
+			fmt.Sprintf("subject changed from '%s' to '%s'", old.Subject, next.Subject))
+	}
+
+	if newKey {
+		if bytes.Equal(old.RawSubjectPublicKeyInfo, next.RawSubjectPublicKeyInfo) {
+			violations = append(violations, "public key was not changed")
+		}
+	} else if old.PublicKeyAlgorithm != next.PublicKeyAlgorithm {
+		violations = append(violations,
+			fmt.Sprintf("public key type changed from %s to %s", old.PublicKeyAlgorithm, next.PublicKeyAlgorithm))
+	}
+
+	if next.NotBefore.Before(old.NotBefore) {
+		violations = append(violations,
+			fmt.Sprintf("new certificate is valid from %s, before the old certificate (%s)",
+				next.NotBefore, old.NotBefore))
+	}
+
+	if !next.NotAfter.After(old.NotAfter) {
+		violations = append(violations,
+			fmt.Sprintf("new certificate expires at %s, which is not after the old certificate (%s)",
+				next.NotAfter, old.NotAfter))
+	}
+
+	if !sameExtKeyUsage(old.ExtKeyUsage, next.ExtKeyUsage) {
+		violations = append(violations, "extended key usages differ")
+	}
+
+	return violations
+}
@@ -0,0 +1,48 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"fmt"
+	"testing"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+func TestRotateCertificate(t *testing.T) {
+	now := time.Now()
+	old := &x509.Certificate{
+		RawSubject:              []byte("subject"),
+		RawSubjectPublicKeyInfo: []byte("old key"),
+		PublicKeyAlgorithm:      x509.ECDSA,
+		NotBefore:               now.Add(-OneYear),
+		NotAfter:                now.Add(OneDay),
+		ExtKeyUsage:             []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	next := &x509.Certificate{
+		RawSubject:              []byte("subject"),
+		RawSubjectPublicKeyInfo: []byte("old key"),
+		PublicKeyAlgorithm:      x509.ECDSA,
+		NotBefore:               now,
+		NotAfter:                now.Add(OneYear),
+		ExtKeyUsage:             []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+
+	violations := RotateCertificate(old, next, false)
+	assert.BoolT(t, len(violations) == 0, fmt.Sprintf("certlib: expected no violations, have %v", violations))
+
+	violations = RotateCertificate(old, next, true)
+	assert.BoolT(t, len(violations) == 1, fmt.Sprintf("certlib: expected a re-key with the same key to fail, have %v", violations))
+
+	next.RawSubjectPublicKeyInfo = []byte("new key")
+	next.PublicKeyAlgorithm = x509.Ed25519
+	violations = RotateCertificate(old, next, true)
+	assert.BoolT(t, len(violations) == 0, fmt.Sprintf("certlib: expected a re-key to a new key type to pass, have %v", violations))
+
+	next.RawSubject = []byte("other subject")
+	next.PublicKeyAlgorithm = x509.RSA
+	next.NotAfter = old.NotAfter
+	violations = RotateCertificate(old, next, false)
+	assert.BoolT(t, len(violations) == 3, fmt.Sprintf("certlib: expected three violations, have %v", violations))
+}
@@ -0,0 +1,151 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strings"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"gopkg.in/yaml.v2"
+)
+
+// SANPolicy restricts the subject alternative names a certificate may
+// contain. DNS names are compared case-insensitively. An empty allow
+// list places no restriction on that type of SAN.
+type SANPolicy struct {
+	// AllowedDNSPatterns are globs, as used by path.Match, that
+	// every DNS name must match one of; note that "*" may match
+	// several labels, so "*.example.net" allows
+	// "a.b.example.net".
+	AllowedDNSPatterns []string
+
+	// AllowedIPRanges are the networks that every IP address
+	// must be in one of.
+	AllowedIPRanges []net.IPNet
+
+	// ForbiddenDNSSuffixes are domains that no DNS name may be
+	// in or under, even if it matches an allowed pattern.
+	ForbiddenDNSSuffixes []string
+}
+
+func (p SANPolicy) checkDNS(name string) string {
+	name = strings.ToLower(name)
+	for _, suffix := range p.ForbiddenDNSSuffixes {
+		suffix = strings.ToLower(strings.TrimPrefix(suffix, "."))
+		if name == suffix || strings.HasSuffix(name, "."+suffix) {
+			return fmt.Sprintf("DNS name %s is under forbidden domain %s", name, suffix)
+		}
+	}
+
+	if len(p.AllowedDNSPatterns) == 0 {
+		return ""
+	}
+
+	for _, pattern := range p.AllowedDNSPatterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("DNS name %s doesn't match an allowed pattern", name)
+}
+
+func (p SANPolicy) checkIP(ip net.IP) string {
+	if len(p.AllowedIPRanges) == 0 {
+		return ""
+	}
+
+	for _, ipRange := range p.AllowedIPRanges {
+		if ipRange.Contains(ip) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("IP address %s isn't in an allowed range", ip)
+}
+
+// ValidateSAN checks cert's DNS and IP address SANs against policy.
+// If any of them violate it, a certerr verification error describing
+// every violation is returned. Other types of SAN aren't checked.
+func ValidateSAN(cert *x509.Certificate, policy SANPolicy) error {
+	var violations []string
+	for _, name := range cert.DNSNames {
+		if v := policy.checkDNS(name); v != "" {
+			violations = append(violations, v)
+		}
+	}
+
+	for _, ip := range cert.IPAddresses {
+		if v := policy.checkIP(ip); v != "" {
+			violations = append(violations, v)
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return certerr.VerifyError(certerr.ErrorSourceCertificate,
+		errors.New("SAN policy violations: "+strings.Join(violations, "; ")))
+}
+
+// sanPolicyFile is the YAML form of a SANPolicy.
+type sanPolicyFile struct {
+	AllowedDNS           []string `yaml:"allowed_dns"`
+	AllowedIPs           []string `yaml:"allowed_ips"`
+	ForbiddenDNSSuffixes []string `yaml:"forbidden_dns_suffixes"`
+}
+
+// LoadSANPolicy reads a SANPolicy from a YAML file such as:
+//
+//	allowed_dns:
+//	  - "*.example.net"
+//	allowed_ips:
+//	  - 10.0.0.0/8
+//	forbidden_dns_suffixes:
+//	  - corp.example.net
+//
+// Each entry in allowed_ips is a CIDR block or a single address.
+func LoadSANPolicy(policyFile string) (SANPolicy, error) {
+	var policy SANPolicy
+
+	in, err := os.ReadFile(policyFile)
+	if err != nil {
+		return policy, err
+	}
+
+	var file sanPolicyFile
+	if err = yaml.UnmarshalStrict(in, &file); err != nil {
+		return policy, fmt.Errorf("certlib: parsing SAN policy %s: %w", policyFile, err)
+	}
+
+	for _, cidr := range file.AllowedIPs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return policy, fmt.Errorf("certlib: parsing SAN policy %s: %w", policyFile, err)
+		}
+		policy.AllowedIPRanges = append(policy.AllowedIPRanges, *ipRange)
+	}
+
+	for _, pattern := range file.AllowedDNS {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return policy, fmt.Errorf("certlib: parsing SAN policy %s: bad pattern %q", policyFile, pattern)
+		}
+	}
+
+	policy.AllowedDNSPatterns = file.AllowedDNS
+	policy.ForbiddenDNSSuffixes = file.ForbiddenDNSSuffixes
+	return policy, nil
+}
@@ -0,0 +1,75 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+)
+
+func TestValidateSAN(t *testing.T) {
+	_, internal, err := net.ParseCIDR("10.0.0.0/8")
+	assert.NoErrorT(t, err)
+
+	policy := SANPolicy{
+		AllowedDNSPatterns:   []string{"*.example.net", "example.net"},
+		AllowedIPRanges:      []net.IPNet{*internal},
+		ForbiddenDNSSuffixes: []string{".corp.example.net"},
+	}
+
+	cert := &x509.Certificate{
+		DNSNames:    []string{"example.net", "WWW.example.net", "*.example.net"},
+		IPAddresses: []net.IP{net.ParseIP("10.1.2.3")},
+	}
+	assert.NoErrorT(t, ValidateSAN(cert, policy))
+
+	cert.DNSNames = append(cert.DNSNames, "www.example.com", "db.corp.example.net")
+	cert.IPAddresses = append(cert.IPAddresses, net.ParseIP("192.0.2.1"))
+	err = ValidateSAN(cert, policy)
+	assert.ErrorT(t, err)
+
+	var cerr *certerr.Error
+	assert.BoolT(t, errors.As(err, &cerr) && cerr.Kind == certerr.ErrorKindVerify,
+		"lib: expected a verification error")
+
+	for _, san := range []string{"www.example.com", "db.corp.example.net", "192.0.2.1"} {
+		assert.BoolT(t, strings.Contains(err.Error(), san), "lib: expected a violation for "+san)
+	}
+
+	assert.NoErrorT(t, ValidateSAN(cert, SANPolicy{}))
+}
+
+func TestLoadSANPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.yaml")
+	err := os.WriteFile(policyFile, []byte(`allowed_dns:
+  - "*.example.net"
+allowed_ips:
+  - 10.0.0.0/8
+  - 192.0.2.1
+forbidden_dns_suffixes:
+  - corp.example.net
+`), 0644)
+	assert.NoErrorT(t, err)
+
+	policy, err := LoadSANPolicy(policyFile)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, len(policy.AllowedDNSPatterns) == 1 && len(policy.ForbiddenDNSSuffixes) == 1,
+		"lib: expected one DNS pattern and one forbidden suffix")
+	assert.BoolT(t, len(policy.AllowedIPRanges) == 2 && policy.AllowedIPRanges[1].Contains(net.ParseIP("192.0.2.1")),
+		"lib: expected a single address to be allowed as a range")
+
+	for _, bad := range []string{"allowed_ips: [not-an-ip]\n", "allowed_dns: [\"[\"]\n", "unknown_field: true\n"} {
+		err = os.WriteFile(policyFile, []byte(bad), 0644)
+		assert.NoErrorT(t, err)
+
+		_, err = LoadSANPolicy(policyFile)
+		assert.ErrorT(t, err)
+	}
+}
@@ -0,0 +1,45 @@
+// Package ski computes and formats subject key identifiers.
+package ski
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+
+	"git.wntrmute.dev/kyle/goutils/lib"
+)
+
+type subjectPublicKeyInfo struct {
+	Algorithm        pkix.AlgorithmIdentifier
+	SubjectPublicKey asn1.BitString
+}
+
+// ComputeSKI returns the subject key identifier for pub, computed as
+// in RFC 5280 section 4.2.1.2, method 1: the SHA-1 hash of the
+// subjectPublicKey BIT STRING (excluding its tag, length, and unused
+// bits count). This matches the SKI that most CAs put in the
+// certificates they issue, though Go's x509 package has used a
+// truncated SHA-256 hash since Go 1.25.
+func ComputeSKI(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("ski: %w", err)
+	}
+
+	var spki subjectPublicKeyInfo
+	if _, err = asn1.Unmarshal(der, &spki); err != nil {
+		return nil, fmt.Errorf("ski: %w", err)
+	}
+
+	sum := sha1.Sum(spki.SubjectPublicKey.Bytes)
+	return sum[:], nil
+}
+
+// FormatSKI returns raw as a hex string in the given mode; use
+// lib.HexEncodeUpperColon for the form OpenSSL prints.
+func FormatSKI(raw []byte, mode lib.HexEncodeMode) string {
+	return lib.HexEncode(raw, mode)
+}
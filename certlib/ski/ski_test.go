@@ -0,0 +1,70 @@
+package ski
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/lib"
+)
+
+func TestComputeSKI(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subjectPublicKey of an EC key is its uncompressed point.
+	ski, err := ComputeSKI(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	point, err := key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := sha1.Sum(point.Bytes()); !bytes.Equal(ski, expected[:]) {
+		t.Fatalf("ski: expected %x, have %x", expected, ski)
+	}
+
+	// The subjectPublicKey of an Ed25519 key is the key itself.
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ski, err = ComputeSKI(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := sha1.Sum(pub); !bytes.Equal(ski, expected[:]) {
+		t.Fatalf("ski: expected %x, have %x", expected, ski)
+	}
+
+	if _, err = ComputeSKI("not a key"); err == nil {
+		t.Fatal("ski: expected an error for an unsupported key type")
+	}
+}
+
+func TestFormatSKI(t *testing.T) {
+	raw := []byte{0x3a, 0xab, 0xd1}
+	tests := map[lib.HexEncodeMode]string{
+		lib.HexEncodeLower:      "3aabd1",
+		lib.HexEncodeUpper:      "3AABD1",
+		lib.HexEncodeLowerColon: "3a:ab:d1",
+		lib.HexEncodeUpperColon: "3A:AB:D1",
+	}
+
+	for mode, expected := range tests {
+		if s := FormatSKI(raw, mode); s != expected {
+			t.Fatalf("ski: expected %s, have %s", expected, s)
+		}
+	}
+}
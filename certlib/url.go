@@ -0,0 +1,70 @@
+package certlib
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"git.wntrmute.dev/kyle/goutils/lib"
+)
+
+// maxCertificateSize bounds how much of a response LoadCertificateURL
+// will read.
+const maxCertificateSize = 1 << 20
+
+// LoadCertificateURL fetches a single DER or PEM-encoded certificate
+// from an HTTPS URL. If tlsCfg is nil, lib.BaselineTLSConfig is used
+// with verification enabled. As with LoadCertificate, only the first
+// certificate in a PEM bundle is returned.
+func LoadCertificateURL(ctx context.Context, rawURL string, tlsCfg *tls.Config) (*x509.Certificate, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, certerr.LoadingError(certerr.ErrorSourceCertificate, err)
+	}
+
+	if u.Scheme != "https" {
+		return nil, certerr.LoadingError(certerr.ErrorSourceCertificate,
+			fmt.Errorf("unsupported URL scheme %q", u.Scheme))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, certerr.LoadingError(certerr.ErrorSourceCertificate, err)
+	}
+
+	client := lib.NewHTTPClient(lib.DialerOpts{TLSConfig: tlsCfg})
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, certerr.NetworkError(certerr.ErrorSourceCertificate, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, certerr.NetworkError(certerr.ErrorSourceCertificate,
+			fmt.Errorf("fetching %s: %s", rawURL, resp.Status))
+	}
+
+	in, err := io.ReadAll(io.LimitReader(resp.Body, maxCertificateSize))
+	if err != nil {
+		return nil, certerr.NetworkError(certerr.ErrorSourceCertificate, err)
+	}
+
+	// ReadCertificate only recognises PEM when it's at the very
+	// start of the input; servers often add leading whitespace.
+	if trimmed := bytes.TrimSpace(in); bytes.HasPrefix(trimmed, []byte("-----BEGIN")) {
+		in = trimmed
+	}
+
+	cert, _, err := ReadCertificate(in)
+	if err != nil {
+		return nil, certerr.ParsingError(certerr.ErrorSourceCertificate, err)
+	}
+
+	return cert, nil
+}
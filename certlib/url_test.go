@@ -0,0 +1,45 @@
+package certlib
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+)
+
+func TestLoadCertificateURL(t *testing.T) {
+	cert, _ := issueTestCert(t, testCA("url test", time.Now().Add(time.Hour)), nil, nil, nil)
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cert.der", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(cert.Raw)
+	})
+	mux.HandleFunc("/cert.pem", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(append([]byte("\n"), pemCert...))
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	cfg := &tls.Config{RootCAs: roots}
+
+	for _, path := range []string{"/cert.der", "/cert.pem"} {
+		got, err := LoadCertificateURL(context.Background(), srv.URL+path, cfg)
+		assert.NoErrorT(t, err)
+		assert.BoolT(t, got.Equal(cert), "lib: certificate from "+path+" doesn't match")
+	}
+
+	_, err := LoadCertificateURL(context.Background(), srv.URL+"/missing", cfg)
+	assert.ErrorT(t, err)
+
+	_, err = LoadCertificateURL(context.Background(), "http://example.net/cert.der", cfg)
+	assert.ErrorT(t, err)
+}
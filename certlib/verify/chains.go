@@ -0,0 +1,55 @@
+package verify
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// Chains verifies each target with ChainFromFile, using a pool of
+// opts.Workers goroutines so that slow revocation checks or remote
+// hosts don't hold up the rest. It returns one error per target, in
+// the same order as targets; a nil error means the target verified.
+// If w is not nil, a summary line for each target is written to it
+// in order once every target has been checked. If opts is nil, the
+// zero Opts is used.
+func Chains(w io.Writer, targets []string, opts *Opts) []error {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]*VerificationResult, len(targets))
+	errs := make([]error, len(targets))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i], errs[i] = ChainFromFile(targets[i], opts)
+			}
+		}()
+	}
+
+	for i := range targets {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	if w != nil {
+		for i, target := range targets {
+			fmt.Fprintf(w, "%s: %s\n", target, SummaryString(results[i]))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,86 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+)
+
+// writeChains writes n leaf certificates issued by intermediate to a
+// temporary directory, and returns their paths.
+func writeChains(t testing.TB, n int, intermediate *testKeypair) []string {
+	dir := t.TempDir()
+	targets := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("leaf%d.pem", i))
+		chain := certlib.EncodeCertificatesPEM([]*x509.Certificate{newTestLeaf(t, intermediate).cert, intermediate.cert})
+		if err := os.WriteFile(path, chain, 0644); err != nil {
+			t.Fatal(err)
+		}
+		targets = append(targets, path)
+	}
+
+	return targets
+}
+
+func TestChains(t *testing.T) {
+	root := newTestCA(t, "test root", nil)
+	intermediate := newTestCA(t, "test intermediate", root)
+	targets := writeChains(t, 4, intermediate)
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	targets = append(targets[:2], append([]string{missing}, targets[2:]...)...)
+
+	opts := &Opts{Roots: x509.NewCertPool(), Workers: 2}
+	opts.Roots.AddCert(root.cert)
+
+	buf := &bytes.Buffer{}
+	errs := Chains(buf, targets, opts)
+	if len(errs) != len(targets) {
+		t.Fatalf("verify: expected %d errors, have %d", len(targets), len(errs))
+	}
+
+	for i, err := range errs {
+		if (err != nil) != (targets[i] == missing) {
+			t.Fatalf("verify: unexpected result for %s: %v", targets[i], err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(targets) {
+		t.Fatalf("verify: expected %d lines of output, have %d", len(targets), len(lines))
+	}
+
+	for i, line := range lines {
+		if !strings.HasPrefix(line, targets[i]+": ") {
+			t.Fatalf("verify: expected line %d to be for %s, have '%s'", i, targets[i], line)
+		}
+	}
+}
+
+func benchmarkChains(b *testing.B, workers int) {
+	root := newTestCA(b, "test root", nil)
+	intermediate := newTestCA(b, "test intermediate", root)
+	targets := writeChains(b, 20, intermediate)
+
+	opts := &Opts{Roots: x509.NewCertPool(), Workers: workers}
+	opts.Roots.AddCert(root.cert)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Chains(nil, targets, opts)
+	}
+}
+
+func BenchmarkChainsSequential(b *testing.B) {
+	benchmarkChains(b, 1)
+}
+
+func BenchmarkChainsParallel(b *testing.B) {
+	benchmarkChains(b, 0)
+}
@@ -0,0 +1,107 @@
+package verify
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// DefaultCTLogList is the URL of Google's list of known CT logs.
+const DefaultCTLogList = "https://www.gstatic.com/ct/log_list/v3/log_list.json"
+
+// HTTPClient is used to fetch the CT log list.
+var HTTPClient = http.DefaultClient
+
+// ctLog covers both the current log list format, which provides the
+// log ID directly, and the older format, where the log ID has to be
+// computed from the log's public key.
+type ctLog struct {
+	LogID string `json:"log_id"`
+	Key   string `json:"key"`
+}
+
+type ctLogList struct {
+	Logs      []ctLog `json:"logs"`
+	Operators []struct {
+		Logs []ctLog `json:"logs"`
+	} `json:"operators"`
+}
+
+func fetchCTLogIDs(logListURL string) (map[[sha256.Size]byte]bool, error) {
+	resp, err := HTTPClient.Get(logListURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("verify: failed to fetch CT log list: %s", resp.Status)
+	}
+
+	var logList ctLogList
+	if err = json.NewDecoder(resp.Body).Decode(&logList); err != nil {
+		return nil, fmt.Errorf("verify: invalid CT log list: %w", err)
+	}
+
+	logs := logList.Logs
+	for _, operator := range logList.Operators {
+		logs = append(logs, operator.Logs...)
+	}
+
+	logIDs := map[[sha256.Size]byte]bool{}
+	for _, log := range logs {
+		var id [sha256.Size]byte
+		if log.LogID != "" {
+			raw, err := base64.StdEncoding.DecodeString(log.LogID)
+			if err != nil || len(raw) != sha256.Size {
+				return nil, fmt.Errorf("verify: invalid CT log ID %s", log.LogID)
+			}
+			copy(id[:], raw)
+		} else {
+			key, err := base64.StdEncoding.DecodeString(log.Key)
+			if err != nil {
+				return nil, fmt.Errorf("verify: invalid CT log key: %w", err)
+			}
+			id = sha256.Sum256(key)
+		}
+
+		logIDs[id] = true
+	}
+
+	return logIDs, nil
+}
+
+// CheckCertificateTransparency checks the SCTs embedded in cert
+// against the list of CT logs at logListURL. It returns the SCTs that
+// were issued by a known log, and true if there was at least one
+// such SCT. The SCT signatures are not verified.
+func CheckCertificateTransparency(cert *x509.Certificate, logListURL string) (bool, []ct.SignedCertificateTimestamp, error) {
+	scts, err := certlib.SCTListFromCertificate(cert)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if len(scts) == 0 {
+		return false, nil, nil
+	}
+
+	logIDs, err := fetchCTLogIDs(logListURL)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var known []ct.SignedCertificateTimestamp
+	for _, sct := range scts {
+		if logIDs[sct.LogID.KeyID] {
+			known = append(known, sct)
+		}
+	}
+
+	return len(known) > 0, known, nil
+}
@@ -0,0 +1,103 @@
+package verify
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+)
+
+func testSCT(logKey string) ct.SignedCertificateTimestamp {
+	return ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: sha256.Sum256([]byte(logKey))},
+		Timestamp:  uint64(time.Now().Unix() * 1000),
+		Signature: ct.DigitallySigned{
+			Algorithm: cttls.SignatureAndHashAlgorithm{
+				Hash:      cttls.SHA256,
+				Signature: cttls.ECDSA,
+			},
+			Signature: []byte{0},
+		},
+	}
+}
+
+func newTestCTCert(t *testing.T, scts ...ct.SignedCertificateTimestamp) *x509.Certificate {
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "ct.example.net"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	if len(scts) > 0 {
+		sctList, err := certlib.SerializeSCTList(scts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		value, err := asn1.Marshal(sctList)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tmpl.ExtraExtensions = []pkix.Extension{
+			{
+				Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2},
+				Value: value,
+			},
+		}
+	}
+
+	return newTestCert(t, tmpl, nil).cert
+}
+
+func TestCheckCertificateTransparency(t *testing.T) {
+	knownID := sha256.Sum256([]byte("known log"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"operators": [{"logs": [{"log_id": "%s"}]}]}`,
+			base64.StdEncoding.EncodeToString(knownID[:]))
+	}))
+	defer srv.Close()
+
+	cert := newTestCTCert(t, testSCT("known log"), testSCT("unknown log"))
+	ok, scts, err := CheckCertificateTransparency(cert, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || len(scts) != 1 {
+		t.Fatalf("verify: expected one SCT from a known log, have %d (ok: %v)", len(scts), ok)
+	}
+
+	cert = newTestCTCert(t, testSCT("unknown log"))
+	ok, _, err = CheckCertificateTransparency(cert, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Fatal("verify: expected the CT check to fail for an SCT from an unknown log")
+	}
+
+	cert = newTestCTCert(t)
+	ok, _, err = CheckCertificateTransparency(cert, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Fatal("verify: expected the CT check to fail for a certificate without SCTs")
+	}
+}
@@ -0,0 +1,111 @@
+package verify
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"git.wntrmute.dev/kyle/goutils/lib"
+)
+
+// DialTimeout is the maximum amount of time ChainFromFile will wait
+// to fetch a chain from a live host.
+var DialTimeout = 10 * time.Second
+
+// FetchChain connects to the host in an https:// URL and returns the
+// chain it presents. The chain isn't verified here; that's left to
+// the caller.
+func FetchChain(uri string) ([]*x509.Certificate, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	conn, err := lib.DialTLS(context.Background(), addr, lib.DialerOpts{
+		Timeout:   DialTimeout,
+		TLSConfig: lib.BaselineTLSConfig(false),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return conn.ConnectionState().PeerCertificates, nil
+}
+
+// ChainFromFile loads the certificate chain in path and verifies it
+// with Chain. If path is an https:// URL, the chain presented by the
+// host is verified instead, unless opts.FetchURLs is set, in which
+// case the certificate is downloaded from the URL. If opts is nil, the
+// zero Opts is used.
+func ChainFromFile(path string, opts *Opts) (*VerificationResult, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	var chain []*x509.Certificate
+	var err error
+	if strings.HasPrefix(path, "https://") && opts.FetchURLs {
+		var cert *x509.Certificate
+		cert, err = certlib.LoadCertificateURL(context.Background(), path, nil)
+		chain = []*x509.Certificate{cert}
+	} else if strings.HasPrefix(path, "https://") {
+		chain, err = FetchChain(path)
+		if err != nil {
+			err = certerr.NetworkError(certerr.ErrorSourceCertificate, err)
+		}
+	} else {
+		chain, err = certlib.LoadCertificates(path)
+		if err != nil {
+			err = certerr.LoadingError(certerr.ErrorSourceCertificate, err)
+		}
+	}
+
+	if err != nil {
+		return &VerificationResult{Err: err}, err
+	}
+
+	return Chain(chain, *opts)
+}
+
+// VerifyWithIntermediates verifies cert against roots, using the
+// intermediates in the PEM bundle at intermBundlePath in addition to
+// any in opts; an empty path means there is no bundle. opts may be
+// nil, and isn't modified.
+func VerifyWithIntermediates(cert *x509.Certificate, roots *x509.CertPool, intermBundlePath string, opts *Opts) (*VerificationResult, error) {
+	var vopts Opts
+	if opts != nil {
+		vopts = *opts
+	}
+	vopts.Roots = roots
+
+	if intermBundlePath != "" {
+		ints, err := certlib.LoadCertificates(intermBundlePath)
+		if err != nil {
+			err = certerr.LoadingError(certerr.ErrorSourceCertificate, err)
+			return &VerificationResult{Err: err}, err
+		}
+
+		if vopts.Intermediates == nil {
+			vopts.Intermediates = x509.NewCertPool()
+		} else {
+			vopts.Intermediates = vopts.Intermediates.Clone()
+		}
+
+		for _, cert := range ints {
+			vopts.Intermediates.AddCert(cert)
+		}
+	}
+
+	return Chain([]*x509.Certificate{cert}, vopts)
+}
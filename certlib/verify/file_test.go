@@ -0,0 +1,93 @@
+package verify
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+)
+
+func TestChainFromFile(t *testing.T) {
+	root := newTestCA(t, "test root", nil)
+	intermediate := newTestCA(t, "test intermediate", root)
+	leaf := newTestLeaf(t, intermediate)
+
+	path := filepath.Join(t.TempDir(), "chain.pem")
+	chain := certlib.EncodeCertificatesPEM([]*x509.Certificate{leaf.cert, intermediate.cert})
+	if err := os.WriteFile(path, chain, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &Opts{Roots: x509.NewCertPool()}
+	opts.Roots.AddCert(root.cert)
+
+	result, err := ChainFromFile(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Chain) != 3 {
+		t.Fatalf("verify: expected a chain of three certificates, have %d", len(result.Chain))
+	}
+
+	if _, err = ChainFromFile(path, nil); err == nil {
+		t.Fatal("verify: expected the chain to fail against the system roots")
+	}
+
+	if _, err = ChainFromFile(filepath.Join(t.TempDir(), "missing.pem"), opts); err == nil {
+		t.Fatal("verify: expected an error for a missing file")
+	}
+}
+
+func TestChainFromFileURL(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	opts := &Opts{Roots: x509.NewCertPool()}
+	opts.Roots.AddCert(srv.Certificate())
+
+	result, err := ChainFromFile(srv.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Chain[0].Equal(srv.Certificate()) {
+		t.Fatal("verify: expected the chain to start with the server's certificate")
+	}
+}
+
+func TestVerifyWithIntermediates(t *testing.T) {
+	root := newTestCA(t, "test root", nil)
+	intermediate := newTestCA(t, "test intermediate", root)
+	leaf := newTestLeaf(t, intermediate)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	if _, err := VerifyWithIntermediates(leaf.cert, roots, "", nil); err == nil {
+		t.Fatal("verify: expected verification to fail without the intermediate bundle")
+	}
+
+	path := filepath.Join(t.TempDir(), "intermediates.pem")
+	if err := os.WriteFile(path, certlib.EncodeCertificatePEM(intermediate.cert), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &Opts{}
+	result, err := VerifyWithIntermediates(leaf.cert, roots, path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Chain) != 3 {
+		t.Fatalf("verify: expected a chain of three certificates, have %d", len(result.Chain))
+	}
+
+	if opts.Roots != nil || opts.Intermediates != nil {
+		t.Fatal("verify: expected the caller's options to be left unmodified")
+	}
+}
@@ -0,0 +1,76 @@
+package verify
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"golang.org/x/crypto/ocsp"
+)
+
+func ocspError(err error) error {
+	return certerr.VerifyError(certerr.ErrorSourceCertificate, fmt.Errorf("OCSP response: %w", err))
+}
+
+func hasOCSPSigning(cert *x509.Certificate) bool {
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageOCSPSigning {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyOCSPResponse checks a pre-fetched OCSP response, such as a
+// stapled response, for cert: it must be signed by issuer (or by a
+// responder certificate that issuer signed for OCSP signing), must be
+// for cert, must say that the certificate is good, and must be
+// current. A response with no next update time is treated as current,
+// as RFC 6960 allows.
+func VerifyOCSPResponse(resp *ocsp.Response, cert, issuer *x509.Certificate) error {
+	signer := issuer
+	if resp.Certificate != nil && !resp.Certificate.Equal(issuer) {
+		if err := resp.Certificate.CheckSignatureFrom(issuer); err != nil {
+			return ocspError(fmt.Errorf("responder certificate wasn't issued by the issuer: %w", err))
+		}
+
+		// RFC 6960 section 4.2.2.2: a delegated responder must
+		// be authorized by the OCSP signing extended key usage,
+		// or any certificate the issuer signed could vouch for
+		// its siblings.
+		if !hasOCSPSigning(resp.Certificate) {
+			return ocspError(errors.New("responder certificate isn't authorized for OCSP signing"))
+		}
+		signer = resp.Certificate
+	}
+
+	if err := resp.CheckSignatureFrom(signer); err != nil {
+		return ocspError(err)
+	}
+
+	if resp.SerialNumber == nil || resp.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		return ocspError(errors.New("response is for a different certificate"))
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+	case ocsp.Revoked:
+		return ocspError(fmt.Errorf("certificate was revoked at %s", resp.RevokedAt))
+	default:
+		return ocspError(errors.New("certificate status is unknown"))
+	}
+
+	now := time.Now()
+	if !resp.ThisUpdate.Before(now) {
+		return ocspError(fmt.Errorf("response isn't valid until %s", resp.ThisUpdate))
+	}
+
+	if !resp.NextUpdate.IsZero() && !resp.NextUpdate.After(now) {
+		return ocspError(fmt.Errorf("response expired at %s", resp.NextUpdate))
+	}
+
+	return nil
+}
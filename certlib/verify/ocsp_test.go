@@ -0,0 +1,103 @@
+package verify
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestVerifyOCSPResponse(t *testing.T) {
+	root := newTestCA(t, "test root", nil)
+	leaf := newTestLeaf(t, root)
+	other := newTestCA(t, "other root", nil)
+
+	der, err := ocsp.CreateResponse(root.cert, root.cert, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: leaf.cert.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Hour),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}, root.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func() *ocsp.Response {
+		resp, err := ocsp.ParseResponse(der, root.cert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if err = VerifyOCSPResponse(parse(), leaf.cert, root.cert); err != nil {
+		t.Fatal(err)
+	}
+
+	failures := map[string]func(*ocsp.Response){
+		"revoked":      func(resp *ocsp.Response) { resp.Status = ocsp.Revoked },
+		"unknown":      func(resp *ocsp.Response) { resp.Status = ocsp.Unknown },
+		"not yet":      func(resp *ocsp.Response) { resp.ThisUpdate = time.Now().Add(time.Hour) },
+		"expired":      func(resp *ocsp.Response) { resp.NextUpdate = time.Now().Add(-time.Minute) },
+		"bad sig":      func(resp *ocsp.Response) { resp.Signature[len(resp.Signature)-1] ^= 0xff },
+		"wrong serial": func(resp *ocsp.Response) { resp.SerialNumber = other.cert.SerialNumber },
+	}
+
+	for name, corrupt := range failures {
+		resp := parse()
+		corrupt(resp)
+		if err = VerifyOCSPResponse(resp, leaf.cert, root.cert); err == nil {
+			t.Fatalf("verify: expected the '%s' OCSP response to fail verification", name)
+		}
+	}
+
+	if err = VerifyOCSPResponse(parse(), leaf.cert, other.cert); err == nil {
+		t.Fatal("verify: expected a response from the wrong issuer to fail verification")
+	}
+}
+
+func TestVerifyOCSPResponseDelegated(t *testing.T) {
+	root := newTestCA(t, "test root", nil)
+	leaf := newTestLeaf(t, root)
+
+	respond := func(usages []x509.ExtKeyUsage) *ocsp.Response {
+		responder := newTestCert(t, &x509.Certificate{
+			SerialNumber: big.NewInt(time.Now().UnixNano()),
+			Subject:      pkix.Name{CommonName: "test responder"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  usages,
+		}, root)
+
+		der, err := ocsp.CreateResponse(root.cert, responder.cert, ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: leaf.cert.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Hour),
+			NextUpdate:   time.Now().Add(time.Hour),
+			Certificate:  responder.cert,
+		}, responder.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := ocsp.ParseResponse(der, root.cert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := respond([]x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning})
+	if err := VerifyOCSPResponse(resp, leaf.cert, root.cert); err != nil {
+		t.Fatalf("verify: expected a response from an OCSP signing responder to verify: %v", err)
+	}
+
+	resp = respond([]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+	if err := VerifyOCSPResponse(resp, leaf.cert, root.cert); err == nil {
+		t.Fatal("verify: expected a response from a responder without the OCSP signing EKU to fail verification")
+	}
+}
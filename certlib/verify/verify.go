@@ -0,0 +1,402 @@
+// Package verify provides certificate chain verification, with
+// optional revocation checking.
+package verify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"git.wntrmute.dev/kyle/goutils/certlib/revoke"
+)
+
+// RevocationStatus records the outcome of a revocation check.
+type RevocationStatus uint8
+
+const (
+	// RevocationNotChecked means that no revocation check was
+	// requested.
+	RevocationNotChecked RevocationStatus = iota
+
+	// RevocationGood means that the certificate was checked and
+	// has not been revoked.
+	RevocationGood
+
+	// RevocationRevoked means that the certificate has been
+	// revoked.
+	RevocationRevoked
+
+	// RevocationUnknown means that the revocation check failed,
+	// e.g. due to a network error.
+	RevocationUnknown
+)
+
+func (s RevocationStatus) String() string {
+	switch s {
+	case RevocationNotChecked:
+		return "not checked"
+	case RevocationGood:
+		return "good"
+	case RevocationRevoked:
+		return "revoked"
+	case RevocationUnknown:
+		return "unknown"
+	default:
+		return fmt.Sprintf("RevocationStatus(%d)", s)
+	}
+}
+
+// WeakSignatureAlgorithm returns true if alg uses a hash that is no
+// longer considered secure for signatures (MD2, MD5, or SHA-1).
+func WeakSignatureAlgorithm(alg x509.SignatureAlgorithm) bool {
+	switch alg {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return true
+	default:
+		return false
+	}
+}
+
+// StrongSignatureAlgorithm returns true if alg is a known signature
+// algorithm that isn't weak; it is suitable for allow-listing, as an
+// unknown algorithm is never strong.
+func StrongSignatureAlgorithm(alg x509.SignatureAlgorithm) bool {
+	return alg != x509.UnknownSignatureAlgorithm && !WeakSignatureAlgorithm(alg)
+}
+
+// evPolicy is the CA/Browser Forum extended validation policy OID.
+var evPolicy = asn1.ObjectIdentifier{2, 23, 140, 1, 1}
+
+// VerificationResult contains the outcome of verifying a certificate.
+type VerificationResult struct {
+	// Chain is the verified chain, starting with the leaf
+	// certificate and ending with the root.
+	Chain []*x509.Certificate
+
+	// RevocationStatus is the revocation status of the leaf
+	// certificate.
+	RevocationStatus RevocationStatus
+
+	// EV is true if the leaf certificate asserts the CA/Browser
+	// Forum extended validation policy.
+	EV bool
+
+	// Violations lists any policy problems found with a chain
+	// that otherwise verified.
+	Violations []string
+
+	// Err is the error that caused verification to fail.
+	Err error
+}
+
+// OK returns true if the certificate verified and there were no
+// violations.
+func (r *VerificationResult) OK() bool {
+	return r.Err == nil && len(r.Violations) == 0
+}
+
+func hasPolicy(cert *x509.Certificate, policy asn1.ObjectIdentifier) bool {
+	for _, id := range cert.PolicyIdentifiers {
+		if id.Equal(policy) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isEV(cert *x509.Certificate) bool {
+	return hasPolicy(cert, evPolicy)
+}
+
+// checkRevocation records the revocation status of cert. A revoked
+// certificate, or one whose status couldn't be determined when
+// revoke.HardFail is set, also sets r.Err to the *revoke.RevocationError
+// describing why.
+func (r *VerificationResult) checkRevocation(cert *x509.Certificate) {
+	revoked, ok, err := revoke.VerifyCertificateError(cert)
+	switch {
+	case !ok:
+		r.RevocationStatus = RevocationUnknown
+		if revoke.HardFail {
+			r.Violations = append(r.Violations, "revocation status could not be determined")
+			r.Err = err
+		}
+	case revoked:
+		r.RevocationStatus = RevocationRevoked
+		r.Violations = append(r.Violations, "certificate has been revoked")
+		r.Err = err
+	default:
+		r.RevocationStatus = RevocationGood
+	}
+}
+
+// Opts controls how certificates are verified. The zero value
+// verifies against the system roots with no intermediates and no
+// revocation checking.
+type Opts struct {
+	// Roots is the set of trusted root certificates; if it is nil,
+	// the system roots are used.
+	Roots *x509.CertPool
+
+	// Intermediates is an optional pool of intermediate
+	// certificates.
+	Intermediates *x509.CertPool
+
+	// CheckRevocation enables checking the revocation status of
+	// the leaf certificate.
+	CheckRevocation bool
+
+	// MaxValidityDays, if non-zero, is the maximum lifetime of the
+	// leaf certificate in days; longer-lived certificates fail
+	// verification.
+	MaxValidityDays int
+
+	// PinnedKeys, if not empty, lists the SHA-256 hashes of the
+	// acceptable leaf SubjectPublicKeyInfos. A chain whose leaf
+	// key doesn't match any of them fails verification.
+	PinnedKeys [][]byte
+
+	// RequiredPolicies, if not empty, lists certificate policy
+	// OIDs of which the leaf must assert at least one.
+	RequiredPolicies []asn1.ObjectIdentifier
+
+	// ForbiddenPolicies lists certificate policy OIDs that the
+	// leaf must not assert.
+	ForbiddenPolicies []asn1.ObjectIdentifier
+
+	// Workers is the number of targets Chains verifies
+	// concurrently; if it is zero, runtime.NumCPU() is used.
+	Workers int
+
+	// FetchURLs makes ChainFromFile, and so Chains, treat an
+	// https:// target as the URL of a certificate to download
+	// rather than a host whose chain should be verified.
+	FetchURLs bool
+}
+
+func checkPins(cert *x509.Certificate, pins [][]byte) error {
+	if len(pins) == 0 {
+		return nil
+	}
+
+	spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	for _, pin := range pins {
+		if bytes.Equal(pin, spki[:]) {
+			return nil
+		}
+	}
+
+	return certerr.VerifyError(certerr.ErrorSourceCertificate,
+		fmt.Errorf("public key (SHA-256 %x) doesn't match any of the %d pinned keys", spki, len(pins)))
+}
+
+func checkPolicies(cert *x509.Certificate, required, forbidden []asn1.ObjectIdentifier) error {
+	for _, policy := range forbidden {
+		if hasPolicy(cert, policy) {
+			return certerr.VerifyError(certerr.ErrorSourceCertificate,
+				fmt.Errorf("certificate asserts forbidden policy %s", policy))
+		}
+	}
+
+	if len(required) == 0 {
+		return nil
+	}
+
+	for _, policy := range required {
+		if hasPolicy(cert, policy) {
+			return nil
+		}
+	}
+
+	return certerr.VerifyError(certerr.ErrorSourceCertificate,
+		fmt.Errorf("certificate doesn't assert any of the %d required policies", len(required)))
+}
+
+func checkValidity(cert *x509.Certificate, maxDays int) error {
+	if maxDays == 0 {
+		return nil
+	}
+
+	validity := cert.NotAfter.Sub(cert.NotBefore)
+	if validity > time.Duration(maxDays)*24*time.Hour {
+		return certerr.VerifyError(certerr.ErrorSourceCertificate,
+			fmt.Errorf("certificate is valid for %d days, more than the maximum of %d days",
+				int(validity.Hours()/24), maxDays))
+	}
+
+	return nil
+}
+
+// CertWith verifies cert using the roots and intermediates in opts.
+// If opts.CheckRevocation is set, a revoked certificate fails
+// verification, as does one whose revocation status couldn't be
+// determined when revoke.HardFail is set. The returned error is the
+// same as the result's Err field; a result is always returned.
+func CertWith(cert *x509.Certificate, opts Opts) (*VerificationResult, error) {
+	result := &VerificationResult{}
+	if err := checkValidity(cert, opts.MaxValidityDays); err != nil {
+		result.Err = err
+		return result, err
+	}
+
+	verifyOpts := x509.VerifyOptions{
+		Intermediates: opts.Intermediates,
+		Roots:         opts.Roots,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	chains, err := cert.Verify(verifyOpts)
+	if err != nil {
+		var uaErr x509.UnknownAuthorityError
+		if errors.As(err, &uaErr) {
+			err = fmt.Errorf("%w: %w", certerr.ErrCertificateChainIncomplete, err)
+		}
+
+		result.Err = certerr.VerifyError(certerr.ErrorSourceCertificate, err)
+		return result, result.Err
+	}
+
+	if err = checkPins(cert, opts.PinnedKeys); err != nil {
+		result.Err = err
+		return result, err
+	}
+
+	if err = checkPolicies(cert, opts.RequiredPolicies, opts.ForbiddenPolicies); err != nil {
+		result.Err = err
+		return result, err
+	}
+
+	result.Chain = chains[0]
+	result.EV = isEV(cert)
+	if opts.CheckRevocation {
+		result.checkRevocation(cert)
+	}
+
+	return result, result.Err
+}
+
+// Chain verifies a certificate chain, such as one read from a PEM
+// bundle: the first certificate is the leaf, and the remaining
+// certificates are used as intermediates in addition to any in opts.
+func Chain(chain []*x509.Certificate, opts Opts) (*VerificationResult, error) {
+	if len(chain) == 0 {
+		err := certerr.VerifyError(certerr.ErrorSourceCertificate, errors.New("empty certificate chain"))
+		return &VerificationResult{Err: err}, err
+	}
+
+	if opts.Intermediates == nil {
+		opts.Intermediates = x509.NewCertPool()
+	} else {
+		opts.Intermediates = opts.Intermediates.Clone()
+	}
+
+	for _, cert := range chain[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	return CertWith(chain[0], opts)
+}
+
+// ValidatePathLengthConstraints checks that no CA certificate in chain
+// is followed by more intermediates than its basic constraints allow.
+// The chain is ordered from the leaf with certlib.NormalizeChain
+// first, and only the certificates that form a path from the leaf are
+// checked; unrelated certificates left at the end are ignored. It
+// returns a description of each violation; an empty slice means the
+// chain satisfies every path length constraint.
+func ValidatePathLengthConstraints(chain []*x509.Certificate) []string {
+	var violations []string
+
+	chain = certlib.NormalizeChain(chain)
+	for i, cert := range chain {
+		if i == 0 {
+			continue
+		}
+
+		if !bytes.Equal(chain[i-1].RawIssuer, cert.RawSubject) {
+			break
+		}
+
+		if !cert.BasicConstraintsValid || !cert.IsCA {
+			continue
+		}
+
+		if cert.MaxPathLen < 0 || (cert.MaxPathLen == 0 && !cert.MaxPathLenZero) {
+			continue
+		}
+
+		// The leaf doesn't count towards the path length.
+		below := i - 1
+		if below > cert.MaxPathLen {
+			violations = append(violations,
+				fmt.Sprintf("%s has a maximum path length of %d, but %d intermediates were issued below it",
+					cert.Subject.CommonName, cert.MaxPathLen, below))
+		}
+	}
+
+	return violations
+}
+
+func keyDescription(cert *x509.Certificate) string {
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA, x509.ECDSA:
+		return fmt.Sprintf("%s-%d", cert.PublicKeyAlgorithm, certlib.KeyLength(cert.PublicKey))
+	default:
+		return cert.PublicKeyAlgorithm.String()
+	}
+}
+
+// SummaryString returns a one-line description of result, such as
+// "OK (RSA-2048, valid until 2026-01-15, revocation good)" or
+// "FAILED: " followed by the error or violations.
+func SummaryString(result *VerificationResult) string {
+	if result.Err != nil {
+		return "FAILED: " + result.Err.Error()
+	}
+
+	if len(result.Violations) > 0 {
+		return "FAILED: " + strings.Join(result.Violations, "; ")
+	}
+
+	if len(result.Chain) == 0 {
+		return "FAILED: no verified chain"
+	}
+
+	leaf := result.Chain[0]
+	details := []string{
+		keyDescription(leaf),
+		"valid until " + leaf.NotAfter.Format("2006-01-02"),
+	}
+
+	if result.RevocationStatus != RevocationNotChecked {
+		details = append(details, "revocation "+result.RevocationStatus.String())
+	}
+
+	return fmt.Sprintf("OK (%s)", strings.Join(details, ", "))
+}
+
+// ChainToString returns a compact, single-line description of chain
+// suitable for audit logs, such as
+// "leaf.example.net[serial=7B] → Example CA[serial=1C8]". The chain
+// is ordered from the leaf with certlib.NormalizeChain first.
+func ChainToString(chain []*x509.Certificate) string {
+	names := make([]string, 0, len(chain))
+	for _, cert := range certlib.NormalizeChain(chain) {
+		name := cert.Subject.CommonName
+		if name == "" {
+			name = cert.Subject.String()
+		}
+
+		names = append(names, fmt.Sprintf("%s[serial=%X]", name, cert.SerialNumber))
+	}
+
+	return strings.Join(names, " → ")
+}
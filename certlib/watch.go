@@ -0,0 +1,170 @@
+package certlib
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+)
+
+// CertificateWatcher holds a TLS keypair loaded from disk and reloads
+// it when the files change. Its GetCertificate and
+// GetClientCertificate methods can be used as the corresponding hooks
+// in a tls.Config, so that servers and clients pick up a rotated
+// certificate on their next handshake without restarting.
+type CertificateWatcher struct {
+	certFile string
+	keyFile  string
+
+	lock    sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+	onError func(error)
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// keypairModTime returns the most recent modification time of the
+// certificate and key files.
+func keypairModTime(certFile, keyFile string) (time.Time, error) {
+	certInfo, err := os.Stat(certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	keyInfo, err := os.Stat(keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+
+	return certInfo.ModTime(), nil
+}
+
+// WatchCertificate loads the keypair in certFile and keyFile, and
+// checks the files for changes every poll interval. When either file
+// changes, the keypair is reloaded and swapped in; connections that
+// were already established are unaffected. If the new keypair can't
+// be loaded, for example because only one of the files has been
+// replaced so far, the old keypair is kept and the reload is retried
+// at the next interval; use OnError to be told about failed reloads.
+// Call Close to stop watching.
+func WatchCertificate(certFile, keyFile string, poll time.Duration) (*CertificateWatcher, error) {
+	if poll <= 0 {
+		return nil, errors.New("certlib: poll interval must be positive")
+	}
+
+	w := &CertificateWatcher{
+		certFile: certFile,
+		keyFile:  keyFile,
+		done:     make(chan struct{}),
+	}
+
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.watch(poll)
+	return w, nil
+}
+
+func (w *CertificateWatcher) reload() error {
+	modTime, err := keypairModTime(w.certFile, w.keyFile)
+	if err != nil {
+		return certerr.LoadingError(certerr.ErrorSourceKeypair, err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(w.certFile, w.keyFile)
+	if err != nil {
+		return certerr.LoadingError(certerr.ErrorSourceKeypair, err)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.cert = &cert
+	w.modTime = modTime
+	return nil
+}
+
+func (w *CertificateWatcher) watch(poll time.Duration) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		modTime, err := keypairModTime(w.certFile, w.keyFile)
+		if err != nil {
+			w.reportError(certerr.LoadingError(certerr.ErrorSourceKeypair, err))
+			continue
+		}
+
+		w.lock.RLock()
+		changed := !modTime.Equal(w.modTime)
+		w.lock.RUnlock()
+
+		if changed {
+			if err = w.reload(); err != nil {
+				w.reportError(err)
+			}
+		}
+	}
+}
+
+// OnError sets a function to be called with the error whenever the
+// keypair files can't be checked or reloaded. Since a failed reload is
+// retried at the next interval, f is called once per attempt until
+// the files can be loaded again. A nil f stops errors from being
+// reported, which is the default.
+func (w *CertificateWatcher) OnError(f func(error)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.onError = f
+}
+
+func (w *CertificateWatcher) reportError(err error) {
+	w.lock.RLock()
+	f := w.onError
+	w.lock.RUnlock()
+
+	if f != nil {
+		f(err)
+	}
+}
+
+// Certificate returns the current keypair.
+func (w *CertificateWatcher) Certificate() *tls.Certificate {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.cert
+}
+
+// GetCertificate returns the current keypair; it is suitable for use
+// as tls.Config.GetCertificate.
+func (w *CertificateWatcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return w.Certificate(), nil
+}
+
+// GetClientCertificate returns the current keypair; it is suitable
+// for use as tls.Config.GetClientCertificate.
+func (w *CertificateWatcher) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return w.Certificate(), nil
+}
+
+// Close stops watching the keypair files. The last keypair loaded
+// remains available.
+func (w *CertificateWatcher) Close() error {
+	w.closeOnce.Do(func() { close(w.done) })
+	return nil
+}
@@ -0,0 +1,101 @@
+package certlib
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/assert"
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+)
+
+// writeKeypair writes a new self-signed certificate and its key to
+// certFile and keyFile, returning the certificate's DER encoding.
+func writeKeypair(t *testing.T, certFile, keyFile string) []byte {
+	cert, key := issueTestCert(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "watch.example.net"},
+	}, nil, nil, nil)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoErrorT(t, err)
+
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	assert.NoErrorT(t, err)
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644)
+	assert.NoErrorT(t, err)
+
+	return cert.Raw
+}
+
+func TestWatchCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	der := writeKeypair(t, certFile, keyFile)
+
+	w, err := WatchCertificate(certFile, keyFile, 10*time.Millisecond)
+	assert.NoErrorT(t, err)
+	defer w.Close()
+
+	cert, err := w.GetCertificate(nil)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, string(cert.Certificate[0]) == string(der), "lib: expected the initial certificate")
+
+	der = writeKeypair(t, certFile, keyFile)
+
+	// Make sure the change is visible even on filesystems with
+	// coarse modification times.
+	future := time.Now().Add(time.Minute)
+	assert.NoErrorT(t, os.Chtimes(certFile, future, future))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		cert, err = w.GetClientCertificate(nil)
+		assert.NoErrorT(t, err)
+		if string(cert.Certificate[0]) == string(der) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.BoolT(t, string(cert.Certificate[0]) == string(der), "lib: expected the replaced certificate to be loaded")
+
+	// A certificate that can't be loaded is reported, and the last
+	// good keypair is kept.
+	errs := make(chan error, 1)
+	w.OnError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	assert.NoErrorT(t, os.WriteFile(certFile, []byte("not a certificate"), 0644))
+	future = future.Add(time.Minute)
+	assert.NoErrorT(t, os.Chtimes(certFile, future, future))
+
+	select {
+	case err = <-errs:
+		var cerr *certerr.Error
+		assert.BoolT(t, errors.As(err, &cerr) && cerr.Kind == certerr.ErrorKindLoad,
+			fmt.Sprintf("certlib: expected a loading error, have %v", err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("certlib: expected a failed reload to be reported")
+	}
+
+	cert, err = w.GetCertificate(nil)
+	assert.NoErrorT(t, err)
+	assert.BoolT(t, string(cert.Certificate[0]) == string(der), "certlib: expected the last good certificate to be kept")
+
+	_, err = WatchCertificate(filepath.Join(dir, "missing.pem"), keyFile, time.Second)
+	assert.ErrorT(t, err, "lib: expected a missing certificate to fail")
+
+	_, err = WatchCertificate(certFile, keyFile, 0)
+	assert.ErrorT(t, err, "lib: expected a zero poll interval to fail")
+}
@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
+	"sort"
 	"time"
+
+	"git.wntrmute.dev/kyle/goutils/lib"
 )
 
 const (
-	defaultServer = "google.com"
-	defaultPort   = "80"
+	defaultServer    = "google.com"
+	defaultPort      = "80"
+	defaultHTTPSPort = "443"
 )
 
 var verbose bool
@@ -46,19 +52,115 @@ func connect(addr string, dport string, six bool, timeout time.Duration) error {
 	return nil
 }
 
+func printHeaders(hdr http.Header) {
+	keys := make([]string, 0, len(hdr))
+	for k := range hdr {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range hdr[k] {
+			fmt.Printf("\t%s: %s\n", k, v)
+		}
+	}
+}
+
+// ping sends a HEAD request to the server, treating any non-5xx
+// response as success.
+func ping(addr string, dport string, six bool, secure bool, verify bool, timeout time.Duration) error {
+	_, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		addr = net.JoinHostPort(addr, dport)
+	}
+
+	proto := "tcp"
+	if six {
+		proto += "6"
+	}
+
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, proto, addr)
+			},
+			TLSClientConfig: lib.BaselineTLSConfig(verify),
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	url := scheme + "://" + addr + "/"
+	if verbose {
+		fmt.Printf("HEAD %s (%s)... ", url, proto)
+		os.Stdout.Sync()
+	}
+
+	resp, err := client.Head(url)
+	if err != nil {
+		if verbose {
+			fmt.Println("failed.")
+		}
+		return err
+	}
+	resp.Body.Close()
+
+	if verbose {
+		fmt.Println(resp.Status)
+		printHeaders(resp.Header)
+	}
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	return nil
+}
+
 func main() {
 	var (
-		port    string
-		timeout time.Duration
-		six     bool
+		port       string
+		timeout    time.Duration
+		six        bool
+		useHTTP    bool
+		useHTTPS   bool
+		certVerify bool
 	)
 
 	flag.BoolVar(&six, "6", false, "require IPv6")
-	flag.StringVar(&port, "p", defaultPort, "`port` to connect to instead of "+defaultPort)
+	flag.BoolVar(&certVerify, "cert-verify", false, "verify the server's certificate in HTTPS mode")
+	flag.BoolVar(&useHTTP, "http", false, "send an HTTP HEAD request and check for a non-5xx response")
+	flag.BoolVar(&useHTTPS, "https", false, "send an HTTPS HEAD request and check for a non-5xx response")
+	flag.StringVar(&port, "p", defaultPort, "`port` to connect to instead of "+defaultPort+" ("+defaultHTTPSPort+" in HTTPS mode)")
 	flag.DurationVar(&timeout, "t", 3*time.Second, "`timeout`")
 	flag.BoolVar(&verbose, "v", false, "verbose mode: print server and protocol when connecting")
 	flag.Parse()
 
+	if useHTTP && useHTTPS {
+		lib.Errx(lib.ExitFailure, "only one of -http and -https may be specified")
+	}
+
+	if useHTTPS {
+		portSet := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "p" {
+				portSet = true
+			}
+		})
+
+		if !portSet {
+			port = defaultHTTPSPort
+		}
+	}
+
 	var servers []string
 	if flag.NArg() == 0 {
 		servers = []string{defaultServer}
@@ -67,9 +169,18 @@ func main() {
 	}
 
 	for _, server := range servers {
-		err := connect(server, port, six, timeout)
+		var err error
+		if useHTTP || useHTTPS {
+			err = ping(server, port, six, useHTTPS, certVerify, timeout)
+		} else {
+			err = connect(server, port, six, timeout)
+		}
+
 		if err != nil {
-			os.Exit(1)
+			if verbose {
+				lib.Warn(err, "%s", server)
+			}
+			os.Exit(lib.ExitFailure)
 		}
 	}
 }
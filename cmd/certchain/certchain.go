@@ -1,20 +1,72 @@
 package main
 
 import (
-	"crypto/tls"
+	"context"
+	"crypto/x509"
 	"encoding/pem"
 	"flag"
 	"fmt"
+	"net"
+	"os"
 	"regexp"
+	"time"
 
+	"git.wntrmute.dev/kyle/goutils/certlib"
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
+	"git.wntrmute.dev/kyle/goutils/certlib/verify"
 	"git.wntrmute.dev/kyle/goutils/die"
+	"git.wntrmute.dev/kyle/goutils/lib"
 )
 
 var hasPort = regexp.MustCompile(`:\d+$`)
 
+// verifyChain checks the chain presented by server against roots (or
+// the system roots, if roots is nil), and checks that the leaf is
+// valid for the server's hostname.
+func verifyChain(server string, chain []*x509.Certificate, roots *x509.CertPool) bool {
+	result, err := verify.Chain(chain, verify.Opts{Roots: roots})
+	if err == nil {
+		host, _, _ := net.SplitHostPort(server)
+		if err = chain[0].VerifyHostname(host); err != nil {
+			err = certerr.VerifyError(certerr.ErrorSourceCertificate, err)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[!] %s: %s\n", server, certerr.FormatError(err))
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "[+] %s: verified chain: %s\n", server, verify.ChainToString(result.Chain))
+	return true
+}
+
 func main() {
+	var caFile string
+	var retries int
+	var verifyChains bool
+	flag.StringVar(&caFile, "ca", "", "verify against the CA certificates in `bundle` instead of the system roots")
+	flag.IntVar(&retries, "r", 0, "retry refused or timed out connections up to `N` times")
+	flag.BoolVar(&verifyChains, "verify", false, "verify each chain after printing it")
+	strict := lib.StrictTLSFlag()
 	flag.Parse()
 
+	var roots *x509.CertPool
+	if caFile != "" {
+		var err error
+		roots, err = certlib.LoadPEMCertPool(caFile)
+		die.IfMsg(err, "loading CA bundle %s", caFile)
+	}
+
+	// With -verify, the chain is fetched without verification so
+	// that it can be printed even if it doesn't verify.
+	cfg := lib.BaselineTLSConfig(!verifyChains)
+	if *strict {
+		cfg = lib.StrictTLSConfig(!verifyChains)
+	}
+	cfg.RootCAs = roots
+
+	failed := false
 	for _, server := range flag.Args() {
 		if !hasPort.MatchString(server) {
 			server += ":443"
@@ -22,10 +74,11 @@ func main() {
 
 		var chain string
 
-		conn, err := tls.Dial("tcp", server, nil)
+		conn, err := lib.DialTLSWithRetry(context.Background(), server, lib.DialerOpts{TLSConfig: cfg}, retries, time.Second)
 		die.If(err)
 
 		details := conn.ConnectionState()
+		conn.Close()
 		for _, cert := range details.PeerCertificates {
 			p := pem.Block{
 				Type:  "CERTIFICATE",
@@ -35,5 +88,13 @@ func main() {
 		}
 
 		fmt.Println(chain)
+
+		if verifyChains && !verifyChain(server, details.PeerCertificates, roots) {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(lib.ExitFailure)
 	}
 }
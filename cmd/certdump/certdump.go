@@ -2,220 +2,57 @@ package main
 
 import (
 	"bytes"
-	"crypto/dsa"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rsa"
-	"crypto/sha256"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"crypto/x509/pkix"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"sort"
 	"strings"
 
 	"git.wntrmute.dev/kyle/goutils/certlib"
+	"git.wntrmute.dev/kyle/goutils/certlib/dump"
+	"git.wntrmute.dev/kyle/goutils/certlib/verify"
 	"git.wntrmute.dev/kyle/goutils/lib"
 )
 
-func certPublic(cert *x509.Certificate) string {
-	switch pub := cert.PublicKey.(type) {
-	case *rsa.PublicKey:
-		return fmt.Sprintf("RSA-%d", pub.N.BitLen())
-	case *ecdsa.PublicKey:
-		switch pub.Curve {
-		case elliptic.P256():
-			return "ECDSA-prime256v1"
-		case elliptic.P384():
-			return "ECDSA-secp384r1"
-		case elliptic.P521():
-			return "ECDSA-secp521r1"
-		default:
-			return "ECDSA (unknown curve)"
-		}
-	case *dsa.PublicKey:
-		return "DSA"
-	default:
-		return "Unknown"
-	}
-}
-
-func displayName(name pkix.Name) string {
-	var ns []string
-
-	if name.CommonName != "" {
-		ns = append(ns, name.CommonName)
-	}
-
-	for i := range name.Country {
-		ns = append(ns, fmt.Sprintf("C=%s", name.Country[i]))
-	}
-
-	for i := range name.Organization {
-		ns = append(ns, fmt.Sprintf("O=%s", name.Organization[i]))
-	}
-
-	for i := range name.OrganizationalUnit {
-		ns = append(ns, fmt.Sprintf("OU=%s", name.OrganizationalUnit[i]))
-	}
-
-	for i := range name.Locality {
-		ns = append(ns, fmt.Sprintf("L=%s", name.Locality[i]))
-	}
+var showHash bool // if true, print a SHA256 hash of the certificate's Raw field
+var verbose bool  // if true, print revocation endpoints and all extensions
+var brief bool    // if true, print a one-line summary of each certificate
+var jsonOut bool  // if true, print the certificates as JSON
+var fetch bool    // if true, download https:// certificates instead of connecting to the host
 
-	for i := range name.Province {
-		ns = append(ns, fmt.Sprintf("ST=%s", name.Province[i]))
-	}
-
-	if len(ns) > 0 {
-		return "/" + strings.Join(ns, "/")
-	}
-
-	return "*** no subject information ***"
-}
-
-func keyUsages(ku x509.KeyUsage) string {
-	var uses []string
-
-	for u, s := range keyUsage {
-		if (ku & u) != 0 {
-			uses = append(uses, s)
-		}
-	}
-	sort.Strings(uses)
-
-	return strings.Join(uses, ", ")
-}
-
-func extUsage(ext []x509.ExtKeyUsage) string {
-	ns := make([]string, 0, len(ext))
-	for i := range ext {
-		ns = append(ns, extKeyUsages[ext[i]])
-	}
-	sort.Strings(ns)
-
-	return strings.Join(ns, ", ")
-}
-
-func showBasicConstraints(cert *x509.Certificate) {
-	fmt.Printf("\tBasic constraints: ")
-	if cert.BasicConstraintsValid {
-		fmt.Printf("valid")
-	} else {
-		fmt.Printf("invalid")
-	}
-
-	if cert.IsCA {
-		fmt.Printf(", is a CA certificate")
-	}
-
-	if (cert.MaxPathLen == 0 && cert.MaxPathLenZero) || (cert.MaxPathLen > 0) {
-		fmt.Printf(", max path length %d", cert.MaxPathLen)
-	}
-
-	fmt.Printf("\n")
-}
-
-const oneTrueDateFormat = "2006-01-02T15:04:05-0700"
-
-var (
-	dateFormat string
-	showHash   bool // if true, print a SHA256 hash of the certificate's Raw field
-)
-
-func wrapPrint(text string, indent int) {
-	tabs := ""
-	for i := 0; i < indent; i++ {
-		tabs += "\t"
-	}
-
-	fmt.Printf(tabs+"%s\n", wrap(text, indent))
-}
+// jsonCerts collects the certificates to print in JSON mode, so that
+// they can be written out as a single array at the end.
+var jsonCerts []json.RawMessage
 
 func displayCert(cert *x509.Certificate) {
-	fmt.Println("CERTIFICATE")
-	if showHash {
-		fmt.Println(wrap(fmt.Sprintf("SHA256: %x", sha256.Sum256(cert.Raw)), 0))
-	}
-	fmt.Println(wrap("Subject: "+displayName(cert.Subject), 0))
-	fmt.Println(wrap("Issuer: "+displayName(cert.Issuer), 0))
-	fmt.Printf("\tSignature algorithm: %s / %s\n", sigAlgoPK(cert.SignatureAlgorithm),
-		sigAlgoHash(cert.SignatureAlgorithm))
-	fmt.Println("Details:")
-	wrapPrint("Public key: "+certPublic(cert), 1)
-	fmt.Printf("\tSerial number: %s\n", cert.SerialNumber)
-
-	if len(cert.AuthorityKeyId) > 0 {
-		fmt.Printf("\t%s\n", wrap("AKI: "+dumpHex(cert.AuthorityKeyId), 1))
-	}
-	if len(cert.SubjectKeyId) > 0 {
-		fmt.Printf("\t%s\n", wrap("SKI: "+dumpHex(cert.SubjectKeyId), 1))
-	}
-
-	wrapPrint("Valid from: "+cert.NotBefore.Format(dateFormat), 1)
-	fmt.Printf("\t     until: %s\n", cert.NotAfter.Format(dateFormat))
-	fmt.Printf("\tKey usages: %s\n", keyUsages(cert.KeyUsage))
-
-	if len(cert.ExtKeyUsage) > 0 {
-		fmt.Printf("\tExtended usages: %s\n", extUsage(cert.ExtKeyUsage))
-	}
-
-	showBasicConstraints(cert)
-
-	validNames := make([]string, 0, len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.IPAddresses))
-	for i := range cert.DNSNames {
-		validNames = append(validNames, "dns:"+cert.DNSNames[i])
-	}
-
-	for i := range cert.EmailAddresses {
-		validNames = append(validNames, "email:"+cert.EmailAddresses[i])
-	}
+	if jsonOut {
+		out, err := dump.CertToJSON(cert, showHash)
+		if err != nil {
+			lib.Warn(err, "couldn't encode certificate as JSON")
+			return
+		}
 
-	for i := range cert.IPAddresses {
-		validNames = append(validNames, "ip:"+cert.IPAddresses[i].String())
+		jsonCerts = append(jsonCerts, out)
+		return
 	}
 
-	sans := fmt.Sprintf("SANs (%d): %s\n", len(validNames), strings.Join(validNames, ", "))
-	wrapPrint(sans, 1)
-
-	l := len(cert.IssuingCertificateURL)
-	if l != 0 {
-		var aia string
-		if l == 1 {
-			aia = "AIA"
-		} else {
-			aia = "AIAs"
-		}
-		wrapPrint(fmt.Sprintf("%d %s:", l, aia), 1)
-		for _, url := range cert.IssuingCertificateURL {
-			wrapPrint(url, 2)
-		}
+	if brief {
+		dump.DisplayCertBrief(os.Stdout, cert)
+		return
 	}
 
-	l = len(cert.OCSPServer)
-	if l > 0 {
-		title := "OCSP server"
-		if l > 1 {
-			title += "s"
-		}
-		wrapPrint(title+":\n", 1)
-		for _, ocspServer := range cert.OCSPServer {
-			wrapPrint(fmt.Sprintf("- %s\n", ocspServer), 2)
-		}
-	}
+	dump.DisplayCert(os.Stdout, cert, showHash, verbose)
 }
 
 func displayAllCerts(in []byte, leafOnly bool) {
-	certs, err := certlib.ParseCertificatesPEM(in)
+	certs, err := certlib.LoadCertificatesAuto(in, "")
 	if err != nil {
-		certs, _, err = certlib.ParseCertificatesDER(in, "")
-		if err != nil {
-			lib.Warn(err, "failed to parse certificates")
-			return
-		}
+		lib.Warn(err, "failed to parse certificates")
+		return
 	}
 
 	if len(certs) == 0 {
@@ -272,9 +109,13 @@ func displayAllCertsWeb(uri string, leafOnly bool) {
 			displayCert(state.PeerCertificates[i])
 		}
 	} else {
-		fmt.Println("TLS chain verified successfully.")
+		if !jsonOut {
+			fmt.Println("TLS chain verified successfully.")
+		}
 		for i := range state.VerifiedChains {
-			fmt.Printf("--- Verified certificate chain %d ---\n", i+1)
+			if !jsonOut {
+				fmt.Printf("--- Verified certificate chain %d ---\n", i+1)
+			}
 			for j := range state.VerifiedChains[i] {
 				displayCert(state.VerifiedChains[i][j])
 			}
@@ -282,13 +123,76 @@ func displayAllCertsWeb(uri string, leafOnly bool) {
 	}
 }
 
+// loadCertificate loads the first certificate from a file. For an
+// https:// URL, it's the leaf presented by the host, or, with -fetch,
+// the certificate downloaded from the URL.
+func loadCertificate(name string) (*x509.Certificate, error) {
+	if !strings.HasPrefix(name, "https://") {
+		return certlib.LoadCertificate(name)
+	}
+
+	if fetch {
+		return certlib.LoadCertificateURL(context.Background(), name, nil)
+	}
+
+	chain, err := verify.FetchChain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%s didn't present any certificates", name)
+	}
+
+	return chain[0], nil
+}
+
+// diffCerts prints a field by field diff of the first certificate in
+// each of two files or URLs, exiting with a failure status if they
+// differ.
+func diffCerts(aFile, bFile string) {
+	a, err := loadCertificate(aFile)
+	if err != nil {
+		lib.Err(lib.ExitFailure, err, "couldn't load certificate from %s", aFile)
+	}
+
+	b, err := loadCertificate(bFile)
+	if err != nil {
+		lib.Err(lib.ExitFailure, err, "couldn't load certificate from %s", bFile)
+	}
+
+	fmt.Printf("--- %s\n+++ %s\n", aFile, bFile)
+	differ, err := dump.DiffCerts(os.Stdout, a, b)
+	if err != nil {
+		lib.Err(lib.ExitFailure, err, "couldn't compare certificates")
+	}
+
+	if differ {
+		os.Exit(lib.ExitFailure)
+	}
+}
+
 func main() {
-	var leafOnly bool
+	var diff, leafOnly bool
+	flag.BoolVar(&brief, "brief", false, "print a one-line summary of each certificate")
 	flag.BoolVar(&showHash, "d", false, "show hashes of raw DER contents")
-	flag.StringVar(&dateFormat, "s", oneTrueDateFormat, "date `format` in Go time format")
+	flag.BoolVar(&diff, "diff", false, "compare two certificates field by field")
+	flag.BoolVar(&fetch, "fetch", false, "download https:// certificates instead of connecting to the host")
+	flag.BoolVar(&jsonOut, "j", false, "print certificates as JSON")
+	flag.StringVar(&dump.DateFormat, "s", dump.OneTrueDateFormat, "date `format` in Go time format")
 	flag.BoolVar(&leafOnly, "l", false, "only show the leaf certificate")
+	flag.BoolVar(&verbose, "v", false, "show CRL distribution points and all extensions")
 	flag.Parse()
 
+	if diff {
+		if flag.NArg() != 2 {
+			lib.Errx(lib.ExitFailure, "Usage: %s -diff cert1 cert2", lib.ProgName())
+		}
+
+		diffCerts(flag.Arg(0), flag.Arg(1))
+		return
+	}
+
 	if flag.NArg() == 0 || (flag.NArg() == 1 && flag.Arg(0) == "-") {
 		certs, err := io.ReadAll(os.Stdin)
 		if err != nil {
@@ -303,8 +207,18 @@ func main() {
 		displayAllCerts(certs, leafOnly)
 	} else {
 		for _, filename := range flag.Args() {
-			fmt.Printf("--%s ---\n", filename)
-			if strings.HasPrefix(filename, "https://") {
+			if !jsonOut {
+				fmt.Printf("--%s ---\n", filename)
+			}
+			if strings.HasPrefix(filename, "https://") && fetch {
+				cert, err := loadCertificate(filename)
+				if err != nil {
+					lib.Warn(err, "couldn't fetch certificate")
+					continue
+				}
+
+				displayCert(cert)
+			} else if strings.HasPrefix(filename, "https://") {
 				displayAllCertsWeb(filename, leafOnly)
 			} else {
 				in, err := os.ReadFile(filename)
@@ -317,4 +231,28 @@ func main() {
 			}
 		}
 	}
+
+	if jsonOut {
+		writeJSON()
+	}
+}
+
+// writeJSON prints the certificates collected in JSON mode: a single
+// certificate is printed as an object, and several as an array.
+func writeJSON() {
+	var out []byte
+	var err error
+	switch len(jsonCerts) {
+	case 0:
+		return
+	case 1:
+		out = jsonCerts[0]
+	default:
+		out, err = json.Marshal(jsonCerts)
+		if err != nil {
+			lib.Errx(lib.ExitFailure, "couldn't encode certificates as JSON: %v", err)
+		}
+	}
+
+	fmt.Println(string(out))
 }
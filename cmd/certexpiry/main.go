@@ -3,9 +3,9 @@ package main
 import (
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -53,48 +53,88 @@ func displayName(name pkix.Name) string {
 	return ""
 }
 
-func expires(cert *x509.Certificate) time.Duration {
-	return cert.NotAfter.Sub(time.Now())
+func expires(notAfter time.Time) time.Duration {
+	return notAfter.Sub(time.Now())
 }
 
-func inDanger(cert *x509.Certificate) bool {
-	return expires(cert) < leeway
+func inDanger(notAfter time.Time) bool {
+	return expires(notAfter) < leeway
 }
 
-func checkCert(cert *x509.Certificate) {
-	warn := inDanger(cert)
+func checkCert(cert *x509.Certificate, notAfter time.Time) {
+	warn := inDanger(notAfter)
 	name := displayName(cert.Subject)
 	name = fmt.Sprintf("%s/SN=%s", name, cert.SerialNumber)
-	expiry := expires(cert)
+	expiry := expires(notAfter)
 	if warnOnly {
 		if warn {
-			fmt.Fprintf(os.Stderr, "%s expires on %s (in %s)\n", name, cert.NotAfter, expiry)
+			fmt.Fprintf(os.Stderr, "%s expires on %s (in %s)\n", name, notAfter, expiry)
 		}
 	} else {
-		fmt.Printf("%s expires on %s (in %s)\n", name, cert.NotAfter, expiry)
+		fmt.Printf("%s expires on %s (in %s)\n", name, notAfter, expiry)
 	}
 }
 
+// loadCertificates loads the certificates in file, in the order they
+// appear, from standard input if file is "-".
+func loadCertificates(file string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	var err error
+	if file == "-" {
+		certs, err = certlib.ReadCertificateStdin()
+	} else {
+		certs, err = certlib.ParseCertificatesPEMFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	return certs, nil
+}
+
+// isCABundle reports whether every certificate in certs is a CA
+// certificate, as in a bundle of trusted roots.
+func isCABundle(certs []*x509.Certificate) bool {
+	for _, cert := range certs {
+		if !cert.IsCA {
+			return false
+		}
+	}
+
+	return true
+}
+
 func main() {
 	flag.BoolVar(&warnOnly, "q", false, "only warn about expiring certs")
 	flag.DurationVar(&leeway, "t", leeway, "warn if certificates are closer than this to expiring")
 	flag.Parse()
 
 	for _, file := range flag.Args() {
-		in, err := ioutil.ReadFile(file)
+		certs, err := loadCertificates(file)
 		if err != nil {
-			lib.Warn(err, "failed to read file")
+			lib.Warn(err, "while loading certificates from %s", file)
 			continue
 		}
 
-		certs, err := certlib.ParseCertificatesPEM(in)
-		if err != nil {
-			lib.Warn(err, "while parsing certificates")
+		// A CA bundle is displayed in the order it was given,
+		// as reordering it would only make it harder to find a
+		// root in the output.
+		if isCABundle(certs) {
+			for _, cert := range certs {
+				checkCert(cert, cert.NotAfter)
+			}
 			continue
 		}
 
-		for _, cert := range certs {
-			checkCert(cert)
+		// Display the leaf first, followed by the rest of the chain.
+		chain := certlib.NormalizeChain(certs)
+		checkCert(chain[0], certlib.LeafExpiryTime(chain))
+		for _, cert := range chain[1:] {
+			checkCert(cert, cert.NotAfter)
 		}
 	}
 }
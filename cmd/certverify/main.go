@@ -1,112 +1,383 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"git.wntrmute.dev/kyle/goutils/certlib"
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
 	"git.wntrmute.dev/kyle/goutils/certlib/revoke"
+	"git.wntrmute.dev/kyle/goutils/certlib/verify"
 	"git.wntrmute.dev/kyle/goutils/die"
 	"git.wntrmute.dev/kyle/goutils/lib"
 )
 
-func printRevocation(cert *x509.Certificate) {
+func printRevocation(cert *x509.Certificate, status verify.RevocationStatus) {
 	remaining := time.Until(cert.NotAfter)
 	fmt.Printf("certificate expires in %s.\n", lib.Duration(remaining))
 
-	revoked, ok := revoke.VerifyCertificate(cert)
-	if !ok {
+	// A revoked certificate fails verification, so only an unknown
+	// status is left to warn about.
+	if status == verify.RevocationUnknown {
 		fmt.Fprintf(os.Stderr, "[!] the revocation check failed (failed to determine whether certificate\nwas revoked)")
-		return
 	}
+}
 
-	if revoked {
-		fmt.Fprintf(os.Stderr, "[!] the certificate has been revoked\n")
-		return
+// isURL reports whether a certificate argument names an HTTPS URL
+// rather than a file.
+func isURL(name string) bool {
+	return strings.HasPrefix(name, "https://")
+}
+
+// loadChain loads the certificate chain named by a certificate
+// argument. An https:// URL names a host whose chain is fetched, or,
+// if fetch is true, a single certificate to download.
+func loadChain(name string, fetch bool) (*x509.Certificate, []*x509.Certificate, error) {
+	if !isURL(name) {
+		return certlib.LoadCertificateChain(name)
+	}
+
+	if fetch {
+		cert, err := certlib.LoadCertificateURL(context.Background(), name, nil)
+		return cert, nil, err
+	}
+
+	chain, err := verify.FetchChain(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(chain) == 0 {
+		return nil, nil, fmt.Errorf("%s didn't present any certificates", name)
+	}
+
+	return chain[0], chain[1:], nil
+}
+
+func checkRotation(oldFile, newFile string, fetch, newKey, verbose bool) {
+	oldCert, _, err := loadChain(oldFile, fetch)
+	die.IfMsg(err, "loading old certificate %s", oldFile)
+
+	newCert, _, err := loadChain(newFile, fetch)
+	die.IfMsg(err, "loading new certificate %s", newFile)
+
+	violations := certlib.RotateCertificate(oldCert, newCert, newKey)
+	if len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "Rotation check failed:\n")
+		for _, violation := range violations {
+			fmt.Fprintf(os.Stderr, "\t%s\n", violation)
+		}
+		os.Exit(1)
+	}
+
+	if verbose {
+		fmt.Println("OK")
+	}
+}
+
+// chainFromDir builds cert's chain from the certificates in dir. The
+// certificates between the leaf and the root are returned to be used
+// as intermediates; if the chain reaches a self-signed root, it is
+// trusted as if it had been in the -ca bundle, and the updated root
+// pool is returned.
+func chainFromDir(cert *x509.Certificate, dir string, roots *x509.CertPool, verbose bool) ([]*x509.Certificate, *x509.CertPool, error) {
+	chain, err := certlib.BuildChainFromDir(cert, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if verbose {
+		fmt.Printf("[+] built chain from %s: %s\n", dir, verify.ChainToString(chain))
+	}
+
+	root := chain[len(chain)-1]
+	if len(chain) == 1 || !selfSigned(root) {
+		return chain[1:], roots, nil
+	}
+
+	if roots == nil {
+		roots = x509.NewCertPool()
+	}
+	roots.AddCert(root)
+
+	return chain[1 : len(chain)-1], roots, nil
+}
+
+// selfSigned reports whether cert is a self-signed root.
+func selfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// lintViolations checks the path length constraints in chain, and
+// that every certificate in it is signed with a strong signature
+// algorithm. A self-signed root is trusted directly rather than
+// through its signature, so its algorithm isn't checked.
+func lintViolations(chain []*x509.Certificate) []string {
+	violations := verify.ValidatePathLengthConstraints(chain)
+	for _, cert := range chain {
+		if !selfSigned(cert) && !verify.StrongSignatureAlgorithm(cert.SignatureAlgorithm) {
+			violations = append(violations, fmt.Sprintf("%s is signed with %s, which isn't a strong signature algorithm",
+				cert.Subject.CommonName, cert.SignatureAlgorithm))
+		}
+	}
+
+	return violations
+}
+
+func lintChain(chain []*x509.Certificate) {
+	violations := lintViolations(chain)
+	if len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "Lint check failed:\n")
+		for _, violation := range violations {
+			fmt.Fprintf(os.Stderr, "\t%s\n", violation)
+		}
+		os.Exit(1)
+	}
+}
+
+func checkChains(targets []string, intFile string, opts *verify.Opts) {
+	if intFile != "" {
+		intermediates, err := certlib.LoadCertificates(intFile)
+		die.IfMsg(err, "loading intermediate bundle %s", intFile)
+
+		for _, intermediate := range intermediates {
+			opts.Intermediates.AddCert(intermediate)
+		}
+	}
+
+	// A certificate that appears in more than one target only
+	// needs its revocation status checked once.
+	if opts.CheckRevocation {
+		revoke.SetGlobalCache(revoke.NewCache(0, 0))
+	}
+
+	failed := false
+	for _, err := range verify.Chains(os.Stdout, targets, opts) {
+		if err != nil {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func checkSANPolicy(cert *x509.Certificate, policyFile string, verbose bool) {
+	policy, err := certlib.LoadSANPolicy(policyFile)
+	die.IfMsg(err, "loading SAN policy %s", policyFile)
+
+	if err = certlib.ValidateSAN(cert, policy); err != nil {
+		fmt.Fprintf(os.Stderr, "SAN policy check failed: %s\n", certerr.FormatError(err))
+		os.Exit(1)
+	}
+
+	if verbose {
+		fmt.Println("[+] SANs comply with the policy in", policyFile)
+	}
+}
+
+func checkCT(cert *x509.Certificate, logList string, verbose bool) {
+	ok, scts, err := verify.CheckCertificateTransparency(cert, logList)
+	die.IfMsg(err, "checking certificate transparency")
+
+	if !ok {
+		fmt.Fprintf(os.Stderr, "[!] no SCTs from known CT logs were found\n")
+		os.Exit(1)
+	}
+
+	if verbose {
+		fmt.Printf("[+] found %d SCTs from known CT logs\n", len(scts))
 	}
 }
 
 func main() {
-	var caFile, intFile string
-	var forceIntermediateBundle, revexp, verbose bool
+	var caFile, chainDir, ctLogList, intFile, sanPolicyFile string
+	var checkTransparency, fetch, forceIntermediateBundle, lint, newKey, revexp, rotation, verbose bool
+	var jobs, maxValidityDays int
+	var caLeeway time.Duration
 	flag.StringVar(&caFile, "ca", "", "CA certificate `bundle`")
+	flag.DurationVar(&caLeeway, "ca-leeway", 30*certlib.OneDay,
+		"warn about CA certificates that expire within `duration`")
+	flag.StringVar(&chainDir, "chain-dir", "", "build the chain from the certificates in `dir`")
+	flag.BoolVar(&checkTransparency, "ct", false, "check for embedded SCTs from known CT logs")
+	flag.StringVar(&ctLogList, "ct-logs", verify.DefaultCTLogList, "`URL` of the CT log list")
 	flag.StringVar(&intFile, "i", "", "intermediate `bundle`")
+	flag.BoolVar(&fetch, "fetch", false, "download https:// certificates instead of verifying the host's chain")
 	flag.BoolVar(&forceIntermediateBundle, "f", false,
 		"force the use of the intermediate bundle, ignoring any intermediates bundled with certificate")
+	flag.IntVar(&jobs, "j", 0, "verify up to `N` certificates at once (default: the number of CPUs)")
+	flag.BoolVar(&lint, "lint", false, "check the certificate chain's path length constraints and signature algorithms")
+	flag.IntVar(&maxValidityDays, "max-validity-days", 0, "fail if the certificate is valid for more than `N` days")
+	flag.BoolVar(&newKey, "new-key", false, "with -check-rotation, require the new certificate to have a new key")
 	flag.BoolVar(&revexp, "r", false, "print revocation and expiry information")
+	flag.BoolVar(&rotation, "check-rotation", false, "check that the second certificate is a valid renewal of the first")
+	flag.StringVar(&sanPolicyFile, "san-policy-file", "", "check the certificate's SANs against the YAML policy in `file`")
 	flag.BoolVar(&verbose, "v", false, "verbose")
 	flag.Parse()
 
+	if rotation {
+		if flag.NArg() != 2 {
+			lib.Errx(lib.ExitFailure, "Usage: %s -check-rotation old new", lib.ProgName())
+		}
+
+		checkRotation(flag.Arg(0), flag.Arg(1), fetch, newKey, verbose)
+		return
+	}
+
+	// The CA bundle may contain intermediates alongside the roots;
+	// only self-signed certificates are trusted as roots, and the
+	// rest are used to build the chain.
 	var roots *x509.CertPool
+	var caInts []*x509.Certificate
 	if caFile != "" {
-		var err error
 		if verbose {
 			fmt.Println("[+] loading root certificates from", caFile)
 		}
-		roots, err = certlib.LoadPEMCertPool(caFile)
-		die.If(err)
-	}
+		caData, err := os.ReadFile(caFile)
+		die.IfMsg(err, "loading CA bundle %s", caFile)
 
-	var ints *x509.CertPool
-	if intFile != "" {
-		var err error
-		if verbose {
-			fmt.Println("[+] loading intermediate certificates from", intFile)
+		caCerts, err := certlib.ReadCertificates(caData)
+		die.IfMsg(err, "loading CA bundle %s", caFile)
+
+		certlib.CheckExpiry(caCerts, caLeeway, func(msg string) {
+			fmt.Fprintf(os.Stderr, "[!] %s: %s\n", caFile, msg)
+		})
+
+		roots, err = certlib.SelfSignedPool(caCerts)
+		if err != nil {
+			if verbose {
+				fmt.Printf("[+] using non-root certificates in %s as intermediates:\n%v\n", caFile, err)
+			}
+			caInts = caCerts
 		}
-		ints, err = certlib.LoadPEMCertPool(caFile)
-		die.If(err)
-	} else {
-		ints = x509.NewCertPool()
 	}
 
-	if flag.NArg() != 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [-ca bundle] [-i bundle] cert",
-			lib.ProgName())
+	ints := x509.NewCertPool()
+	for _, intermediate := range caInts {
+		ints.AddCert(intermediate)
+	}
+
+	if flag.NArg() == 0 {
+		lib.Errx(lib.ExitFailure, "Usage: %s [-ca bundle] [-i bundle] cert...", lib.ProgName())
+	}
+
+	if flag.NArg() > 1 {
+		// These checks are only done for a single certificate;
+		// rather than silently skip them, refuse to run.
+		var single []string
+		for name, set := range map[string]bool{
+			"-chain-dir":       chainDir != "",
+			"-ct":              checkTransparency,
+			"-f":               forceIntermediateBundle,
+			"-lint":            lint,
+			"-san-policy-file": sanPolicyFile != "",
+		} {
+			if set {
+				single = append(single, name)
+			}
+		}
+
+		if len(single) > 0 {
+			sort.Strings(single)
+			lib.Errx(lib.ExitFailure, "%s can only be used with a single certificate",
+				strings.Join(single, ", "))
+		}
+
+		checkChains(flag.Args(), intFile, &verify.Opts{
+			Roots:           roots,
+			Intermediates:   ints,
+			CheckRevocation: revexp,
+			MaxValidityDays: maxValidityDays,
+			Workers:         jobs,
+			FetchURLs:       fetch,
+		})
+		return
 	}
 
-	fileData, err := ioutil.ReadFile(flag.Arg(0))
-	die.If(err)
+	var cert *x509.Certificate
+	var intermediates []*x509.Certificate
+	var err error
+	if flag.Arg(0) == "-" {
+		var chain []*x509.Certificate
+		chain, err = certlib.ReadCertificateStdin()
+		die.IfMsg(err, "reading certificates from standard input")
+		die.When(len(chain) == 0, "no certificates found on standard input")
 
-	chain, err := certlib.ParseCertificatesPEM(fileData)
-	die.If(err)
+		chain = certlib.NormalizeChain(chain)
+		cert, intermediates = chain[0], chain[1:]
+	} else {
+		cert, intermediates, err = loadChain(flag.Arg(0), fetch)
+		die.IfMsg(err, "loading certificates from %s", flag.Arg(0))
+	}
 	if verbose {
-		fmt.Printf("[+] %s has %d certificates\n", flag.Arg(0), len(chain))
+		fmt.Printf("[+] %s has %d certificates\n", flag.Arg(0), len(intermediates)+1)
 	}
 
-	cert := chain[0]
-	if len(chain) > 1 {
-		if !forceIntermediateBundle {
-			for _, intermediate := range chain[1:] {
-				if verbose {
-					fmt.Printf("[+] adding intermediate with SKI %x\n", intermediate.SubjectKeyId)
-				}
+	if chainDir != "" {
+		var dirInts []*x509.Certificate
+		dirInts, roots, err = chainFromDir(cert, chainDir, roots, verbose)
+		die.IfMsg(err, "building the certificate chain from %s", chainDir)
+		intermediates = append(intermediates, dirInts...)
+	}
 
-				ints.AddCert(intermediate)
+	if !forceIntermediateBundle {
+		for _, intermediate := range intermediates {
+			if verbose {
+				fmt.Printf("[+] adding intermediate with SKI %x\n", intermediate.SubjectKeyId)
 			}
+
+			ints.AddCert(intermediate)
 		}
 	}
 
-	opts := x509.VerifyOptions{
-		Intermediates: ints,
-		Roots:         roots,
-		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	if lint {
+		lintChain(append([]*x509.Certificate{cert}, intermediates...))
+	}
+
+	if sanPolicyFile != "" {
+		checkSANPolicy(cert, sanPolicyFile, verbose)
 	}
 
-	_, err = cert.Verify(opts)
+	if verbose && intFile != "" {
+		fmt.Println("[+] loading intermediate certificates from", intFile)
+	}
+
+	result, err := verify.VerifyWithIntermediates(cert, roots, intFile, &verify.Opts{
+		Intermediates:   ints,
+		CheckRevocation: revexp,
+		MaxValidityDays: maxValidityDays,
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Verification failed: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Verification failed: %s\n", certerr.FormatError(err))
+		if errors.Is(err, certerr.ErrCertificateChainIncomplete) {
+			fmt.Fprintln(os.Stderr, "Intermediate certificates may be missing; try passing a bundle with -i.")
+		}
 		os.Exit(1)
 	}
 
 	if verbose {
-		fmt.Println("OK")
+		fmt.Println("[+] verified chain:", verify.ChainToString(result.Chain))
+		if result.EV {
+			fmt.Println("[+] certificate is an EV certificate")
+		}
+		fmt.Println(verify.SummaryString(result))
 	}
 
 	if revexp {
-		printRevocation(cert)
+		printRevocation(cert, result.RevocationStatus)
+	}
+
+	if checkTransparency {
+		checkCT(cert, ctLogList, verbose)
 	}
 }
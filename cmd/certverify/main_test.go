@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git.wntrmute.dev/kyle/goutils/certlib"
+)
+
+// issue creates a certificate for name signed by issuer; a nil issuer
+// makes it self-signed.
+func issue(t *testing.T, name string, isCA bool, issuer *x509.Certificate, issuerKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if issuer == nil {
+		issuer, issuerKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, key.Public(), issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func writeCert(t *testing.T, dir, name string, cert *x509.Certificate) {
+	err := os.WriteFile(filepath.Join(dir, name), certlib.EncodeCertificatePEM(cert), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChainFromDir(t *testing.T) {
+	root, rootKey := issue(t, "root", true, nil, nil)
+	inter, interKey := issue(t, "inter", true, root, rootKey)
+	leaf, _ := issue(t, "leaf", false, inter, interKey)
+
+	dir := t.TempDir()
+	writeCert(t, dir, "root.pem", root)
+	writeCert(t, dir, "inter.crt", inter)
+
+	intermediates, roots, err := chainFromDir(leaf, dir, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(intermediates) != 1 || !intermediates[0].Equal(inter) {
+		t.Fatalf("certverify: expected only the intermediate, have %d certificates", len(intermediates))
+	}
+
+	if roots == nil {
+		t.Fatal("certverify: expected the self-signed root to be trusted")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(inter)
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: pool})
+	if err != nil {
+		t.Fatalf("certverify: leaf doesn't verify against the chain from the directory: %v", err)
+	}
+}
+
+func TestChainFromDirWithoutRoot(t *testing.T) {
+	root, rootKey := issue(t, "root", true, nil, nil)
+	inter, interKey := issue(t, "inter", true, root, rootKey)
+	leaf, _ := issue(t, "leaf", false, inter, interKey)
+
+	dir := t.TempDir()
+	writeCert(t, dir, "inter.pem", inter)
+
+	intermediates, roots, err := chainFromDir(leaf, dir, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(intermediates) != 1 || !intermediates[0].Equal(inter) {
+		t.Fatalf("certverify: expected the intermediate, have %d certificates", len(intermediates))
+	}
+
+	if roots != nil {
+		t.Fatal("certverify: a chain without a self-signed root shouldn't add trusted roots")
+	}
+}
+
+func TestChainFromDirMissing(t *testing.T) {
+	leaf, _ := issue(t, "leaf", false, nil, nil)
+
+	_, _, err := chainFromDir(leaf, filepath.Join(t.TempDir(), "missing"), nil, false)
+	if err == nil {
+		t.Fatal("certverify: expected an error for a missing directory")
+	}
+}
+
+func TestLintViolations(t *testing.T) {
+	root, rootKey := issue(t, "root", true, nil, nil)
+	inter, interKey := issue(t, "inter", true, root, rootKey)
+	leaf, _ := issue(t, "leaf", false, inter, interKey)
+
+	if violations := lintViolations([]*x509.Certificate{leaf, inter, root}); len(violations) != 0 {
+		t.Fatalf("certverify: expected no lint violations, have %v", violations)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:       big.NewInt(time.Now().UnixNano()),
+		Subject:            pkix.Name{CommonName: "sha1 leaf"},
+		NotBefore:          time.Now().Add(-time.Hour),
+		NotAfter:           time.Now().Add(time.Hour),
+		SignatureAlgorithm: x509.ECDSAWithSHA1,
+	}, inter, key.Public(), interKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weak, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations := lintViolations([]*x509.Certificate{weak, inter, root})
+	if len(violations) != 1 || !strings.Contains(violations[0], "sha1 leaf") {
+		t.Fatalf("certverify: expected the SHA-1 signature to be flagged, have %v", violations)
+	}
+}
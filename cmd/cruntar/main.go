@@ -10,9 +10,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"git.wntrmute.dev/kyle/goutils/die"
 	"git.wntrmute.dev/kyle/goutils/fileutil"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
 )
 
 var (
@@ -121,6 +124,8 @@ func processFile(tfr *tar.Reader, hdr *tar.Header, top string) error {
 var compression = map[string]bool{
 	"gzip":  false,
 	"bzip2": false,
+	"xz":    false,
+	"zstd":  false,
 }
 
 type bzipCloser struct {
@@ -140,9 +145,42 @@ func newBzipCloser(r io.ReadCloser) (io.ReadCloser, error) {
 	return &bzipCloser{r: br}, nil
 }
 
+func newXZReader(r io.ReadCloser) (io.ReadCloser, error) {
+	xr, err := xz.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(xr), nil
+}
+
+func newZstdReader(r io.ReadCloser) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return zr.IOReadCloser(), nil
+}
+
 var compressFuncs = map[string]func(io.ReadCloser) (io.ReadCloser, error){
 	"gzip":  func(r io.ReadCloser) (io.ReadCloser, error) { return gzip.NewReader(r) },
 	"bzip2": newBzipCloser,
+	"xz":    newXZReader,
+	"zstd":  newZstdReader,
+}
+
+// compressExts maps archive file extensions to the compression
+// format they imply.
+var compressExts = map[string]string{
+	".tar.gz":  "gzip",
+	".tgz":     "gzip",
+	".tar.bz2": "bzip2",
+	".tbz2":    "bzip2",
+	".tar.xz":  "xz",
+	".txz":     "xz",
+	".tar.zst": "zstd",
+	".tzst":    "zstd",
 }
 
 func verifyCompression() bool {
@@ -156,6 +194,23 @@ func verifyCompression() bool {
 	return true
 }
 
+// detectCompression picks the compression format from the archive's
+// extension if none was given on the command line.
+func detectCompression(path string) {
+	for _, v := range compression {
+		if v {
+			return
+		}
+	}
+
+	for ext, c := range compressExts {
+		if strings.HasSuffix(path, ext) {
+			compression[c] = true
+			return
+		}
+	}
+}
+
 func getReader(r io.ReadCloser) (io.ReadCloser, error) {
 	for c, v := range compression {
 		if v {
@@ -183,6 +238,8 @@ func openArchive(path string) (io.ReadCloser, error) {
 var compressFlags struct {
 	z bool
 	j bool
+	J bool
+	Z bool
 }
 
 func parseCompressFlags() error {
@@ -194,6 +251,14 @@ func parseCompressFlags() error {
 		compression["bzip2"] = true
 	}
 
+	if compressFlags.J {
+		compression["xz"] = true
+	}
+
+	if compressFlags.Z {
+		compression["zstd"] = true
+	}
+
 	if !verifyCompression() {
 		return errors.New("multiple compression formats specified")
 	}
@@ -208,15 +273,20 @@ This is a tool that is intended to support untarring on SquashFS file
 systems. In particular, every time it encounters a hard link, it
 will just create a copy of the file.
 
-Usage: cruntar [-jmvpz] archive [dest]
+Usage: cruntar [-jJmvpzZ] archive [dest]
 
 Flags:
 	-a	Shortcut for -m -p: preserve owners and file mode.
 	-j	The archive is compressed with bzip2.
+	-J	The archive is compressed with xz.
 	-m	Preserve file modes.
 	-p	Preserve ownership.
 	-v	Print the name of each file as it is being processed.
 	-z	The archive is compressed with gzip.
+	-Z	The archive is compressed with zstd.
+
+If no compression flag is given, the compression is chosen from the
+archive's extension (e.g. .tar.gz, .tar.bz2, .tar.xz, or .tar.zst).
 `)
 }
 
@@ -229,10 +299,12 @@ func main() {
 	flag.BoolVar(&archive, "a", false, "Shortcut for -m -p: preserve owners and file mode.")
 	flag.BoolVar(&help, "h", false, "print a help message")
 	flag.BoolVar(&compressFlags.j, "j", false, "bzip2 compression")
+	flag.BoolVar(&compressFlags.J, "J", false, "xz compression")
 	flag.BoolVar(&preserveMode, "m", false, "preserve file modes")
 	flag.BoolVar(&preserveOwners, "p", false, "preserve ownership")
 	flag.BoolVar(&verbose, "v", false, "verbose mode")
 	flag.BoolVar(&compressFlags.z, "z", false, "gzip compression")
+	flag.BoolVar(&compressFlags.Z, "Z", false, "zstd compression")
 	flag.Parse()
 
 	if help {
@@ -257,6 +329,7 @@ func main() {
 		top = flag.Arg(1)
 	}
 
+	detectCompression(flag.Arg(0))
 	r, err := openArchive(flag.Arg(0))
 	die.If(err)
 
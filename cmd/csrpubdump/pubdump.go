@@ -8,8 +8,8 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
 
+	"git.wntrmute.dev/kyle/goutils/certlib/certerr"
 	"git.wntrmute.dev/kyle/goutils/die"
 )
 
@@ -22,7 +22,7 @@ func main() {
 
 		if p, _ := pem.Decode(in); p != nil {
 			if p.Type != "CERTIFICATE REQUEST" {
-				log.Fatal("INVALID FILE TYPE")
+				die.If(certerr.NewPEMTypeError(p.Type, "CERTIFICATE REQUEST"))
 			}
 			in = p.Bytes
 		}
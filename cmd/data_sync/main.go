@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -8,8 +9,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
+	"git.wntrmute.dev/kyle/goutils/ahash"
 	"git.wntrmute.dev/kyle/goutils/config"
 	"git.wntrmute.dev/kyle/goutils/fileutil"
 	"git.wntrmute.dev/kyle/goutils/log"
@@ -33,10 +36,16 @@ var (
 	defaultTargetDir = filepath.Join(defaultMountDir, os.Getenv("USER"))
 )
 
+// manifestName is the file in the target directory that records the
+// SHA-256 sums of the source files as of the last sync.
+const manifestName = ".data_sync.sha256"
+
 func usage(w io.Writer) {
 	prog := filepath.Base(os.Args[0])
-	fmt.Fprintf(w, `Usage: %s [-d path] [-l level] [-m path] [-nqsv]
-				  [-t path]
+	fmt.Fprintf(w, `Usage: %s [-check] [-d path] [-l level] [-m path]
+				  [-nqsv] [-t path]
+	-check		skip the sync if no files have changed since the
+			last one
 	-d path		path to sync source directory
 			(default "%s")
 	-l level	log level to output (default "INFO"). Valid log
@@ -56,10 +65,14 @@ func usage(w io.Writer) {
 directory (-t); it checks the mount directory (-m) exists; the sync target
 target directory must exist on the mount directory.
 
-`, prog, defaultSyncDir, defaultMountDir, defaultTargetDir, prog)
+With -check, the SHA-256 sum of every file to be synced is compared
+against the manifest (%s) written to the target directory by the
+last sync; if nothing has changed, rsync isn't run.
+
+`, prog, defaultSyncDir, defaultMountDir, defaultTargetDir, prog, manifestName)
 }
 
-func checkPaths(mount, target string, dryRun bool) error {
+func checkPaths(ctx context.Context, mount, target string, dryRun bool) error {
 	if !fileutil.DirectoryDoesExist(mount) {
 		return fmt.Errorf("sync dir %s isn't mounted", mount)
 	}
@@ -70,9 +83,9 @@ func checkPaths(mount, target string, dryRun bool) error {
 
 	if !fileutil.DirectoryDoesExist(target) {
 		if dryRun {
-			log.Infof("would mkdir %s", target)
+			log.FromContext(ctx).Infof("would mkdir %s", target)
 		} else {
-			log.Infof("mkdir %s", target)
+			log.FromContext(ctx).Infof("mkdir %s", target)
 			if err := os.Mkdir(target, 0755); err != nil {
 				return err
 			}
@@ -82,36 +95,19 @@ func checkPaths(mount, target string, dryRun bool) error {
 	return nil
 }
 
-func buildExcludes(syncDir string) ([]string, error) {
-	var excluded []string
-
-	walker := func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			excluded = append(excluded, strings.TrimPrefix(path, syncDir))
-			if info != nil && info.IsDir() {
-				return filepath.SkipDir
-			}
-
-			return nil
-		}
-
-		if info.Mode().IsRegular() {
-			if err = fileutil.Access(path, fileutil.AccessRead); err != nil {
-				excluded = append(excluded, strings.TrimPrefix(path, syncDir))
-			}
-		}
-
-		if info.IsDir() {
-			if err = fileutil.Access(path, fileutil.AccessExec); err != nil {
-				excluded = append(excluded, strings.TrimPrefix(path, syncDir))
-			}
-		}
+func buildExcludes(ctx context.Context, syncDir string) ([]string, error) {
+	violations, err := fileutil.AuditPermissions(syncDir, fileutil.PermPolicy{RequireAccess: true})
+	if err != nil {
+		return nil, err
+	}
 
-		return nil
+	var excluded []string
+	for _, v := range violations {
+		log.FromContext(ctx).Debugf("excluding %s: %s", v.Path, v.Rule)
+		excluded = append(excluded, strings.TrimPrefix(v.Path, syncDir))
 	}
 
-	err := filepath.Walk(syncDir, walker)
-	return excluded, err
+	return excluded, nil
 }
 
 func writeExcludes(excluded []string) (string, error) {
@@ -132,7 +128,69 @@ func writeExcludes(excluded []string) (string, error) {
 	return excludeFile.Name(), nil
 }
 
-func rsync(syncDir, target, excludeFile string, verboseRsync bool) error {
+// buildManifest returns the SHA-256 sums of the regular files under
+// syncDir that aren't excluded, in sha256sum format with paths
+// relative to syncDir.
+func buildManifest(ctx context.Context, syncDir string, excluded []string) (string, error) {
+	skip := map[string]bool{}
+	for _, name := range excluded {
+		skip[name] = true
+	}
+
+	var paths []string
+	err := filepath.WalkDir(syncDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if skip[strings.TrimPrefix(path, syncDir)] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	log.FromContext(ctx).Debugf("hashing %d files under %s", len(paths), syncDir)
+	results, err := ahash.SumFiles("sha256", runtime.NumCPU(), paths)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range results {
+		if results[i].Err != nil {
+			return "", results[i].Err
+		}
+
+		results[i].Path, err = filepath.Rel(syncDir, results[i].Path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return ahash.FormatSHA256Sum(results), nil
+}
+
+// targetUpToDate reports whether the manifest in target matches
+// manifest.
+func targetUpToDate(target, manifest string) bool {
+	current, err := os.ReadFile(filepath.Join(target, manifestName))
+	if err != nil {
+		return false
+	}
+
+	return string(current) == manifest
+}
+
+func rsync(ctx context.Context, syncDir, target, excludeFile string, verboseRsync bool) error {
 	var args []string
 
 	if excludeFile != "" {
@@ -152,7 +210,8 @@ func rsync(syncDir, target, excludeFile string, verboseRsync bool) error {
 		return err
 	}
 
-	cmd := exec.Command(path, args...)
+	log.FromContext(ctx).Debugf("running %s %s", path, strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, path, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -165,7 +224,9 @@ func init() {
 func main() {
 
 	var logLevel, mountDir, syncDir, target string
-	var dryRun, quietMode, noSyslog, verboseRsync bool
+	var check, dryRun, quietMode, noSyslog, verboseRsync bool
+
+	flag.BoolVar(&check, "check", false, "skip the sync if no files have changed since the last one")
 
 	flag.StringVar(&syncDir, "d", config.GetDefault("sync_dir", defaultSyncDir),
 		"`path to sync source directory`")
@@ -196,12 +257,17 @@ func main() {
 	err := log.Setup(logOpts)
 	log.FatalError(err, "failed to set up logging")
 
-	log.Infof("checking paths: mount=%s, target=%s", mountDir, target)
-	err = checkPaths(mountDir, target, dryRun)
+	// Tag every message from this run so that runs can be told
+	// apart in syslog.
+	ctx := log.WithLogger(context.Background(), log.WithFields(map[string]any{"pid": os.Getpid()}))
+	logger := log.FromContext(ctx)
+
+	logger.Infof("checking paths: mount=%s, target=%s", mountDir, target)
+	err = checkPaths(ctx, mountDir, target, dryRun)
 	log.FatalError(err, "target dir isn't ready")
 
-	log.Infof("checking for files to exclude from %s", syncDir)
-	excluded, err := buildExcludes(syncDir)
+	logger.Infof("checking for files to exclude from %s", syncDir)
+	excluded, err := buildExcludes(ctx, syncDir)
 	log.FatalError(err, "couldn't build excludes")
 
 	if dryRun {
@@ -212,19 +278,40 @@ func main() {
 		return
 	}
 
+	var manifest string
+	if check {
+		logger.Infof("checking for changes since the last sync")
+		manifest, err = buildManifest(ctx, syncDir, excluded)
+		if err != nil {
+			logger.Warningf("couldn't build manifest, syncing anyway: %s", err)
+			manifest = ""
+		} else if targetUpToDate(target, manifest) {
+			logger.Infof("target already up to date")
+			return
+		}
+	}
+
 	excludeFile, err := writeExcludes(excluded)
 	log.FatalError(err, "couldn't write exclude file")
-	log.Infof("excluding %d files via %s", len(excluded), excludeFile)
+	logger.Infof("excluding %d files via %s", len(excluded), excludeFile)
 
 	if excludeFile != "" {
 		defer func() {
-			log.Infof("removing exclude file %s", excludeFile)
+			logger.Infof("removing exclude file %s", excludeFile)
 			if err := os.Remove(excludeFile); err != nil {
-				log.Warningf("failed to remove temp file %s", excludeFile)
+				logger.Warningf("failed to remove temp file %s", excludeFile)
 			}
 		}()
 	}
 
-	err = rsync(syncDir, target, excludeFile, verboseRsync)
+	err = rsync(ctx, syncDir, target, excludeFile, verboseRsync)
 	log.FatalError(err, "couldn't sync data")
+
+	if manifest != "" {
+		manifestPath := filepath.Join(target, manifestName)
+		logger.Infof("writing manifest %s", manifestPath)
+		if err = fileutil.AtomicWriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+			logger.Warningf("failed to write manifest %s: %s", manifestPath, err)
+		}
+	}
 }
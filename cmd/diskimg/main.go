@@ -101,9 +101,13 @@ func main() {
 	_, err = device.Seek(0, 0)
 	die.If(err)
 
-	deviceHash, err := ahash.SumLimitedReader(hAlgo, device, n)
+	deviceHash, truncated, err := ahash.SumLimitedReaderEx(hAlgo, device, n)
 	die.If(err)
 
+	if truncated {
+		die.With("%s is shorter than the %d-byte image %s", devicePath, n, imageFile)
+	}
+
 	if !bytes.Equal(deviceHash, hash) {
 		fmt.Fprintln(os.Stderr, "Hash mismatch:")
 		fmt.Fprintf(os.Stderr, "\t%s: %s\n", imageFile, hash)
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
 
 	"git.wntrmute.dev/kyle/goutils/lib"
 )
@@ -89,9 +91,138 @@ func compact(file string, validateOnly bool) error {
 	return err
 }
 
+// pathElem is a single step in a -p path: either an object key or an
+// array index.
+type pathElem struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parsePath splits a path such as foo.bar[0].name into its steps.
+func parsePath(path string) ([]pathElem, error) {
+	var elems []pathElem
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			key = part[:i]
+		}
+
+		if key != "" {
+			elems = append(elems, pathElem{key: key})
+		}
+
+		rest := part[len(key):]
+		for rest != "" {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid path element %q", part)
+			}
+
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid array index in %q", part)
+			}
+
+			elems = append(elems, pathElem{index: n, isIndex: true})
+			rest = rest[end+1:]
+		}
+
+		if key == "" && len(part) == len(rest) {
+			return nil, fmt.Errorf("empty path element in %q", path)
+		}
+	}
+
+	return elems, nil
+}
+
+// lookup returns the raw JSON value at path in the document in.
+func lookup(in []byte, path []pathElem) (json.RawMessage, error) {
+	value := json.RawMessage(in)
+	for _, elem := range path {
+		if elem.isIndex {
+			var array []json.RawMessage
+			if err := json.Unmarshal(value, &array); err != nil {
+				return nil, fmt.Errorf("[%d]: not an array", elem.index)
+			}
+
+			if elem.index >= len(array) {
+				return nil, fmt.Errorf("[%d]: index out of range", elem.index)
+			}
+
+			value = array[elem.index]
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(value, &object); err != nil {
+			return nil, fmt.Errorf("%s: not an object", elem.key)
+		}
+
+		var ok bool
+		value, ok = object[elem.key]
+		if !ok {
+			return nil, fmt.Errorf("%s: no such key", elem.key)
+		}
+	}
+
+	return value, nil
+}
+
+// extract prints the value at path in file to standard output; the
+// file itself is never modified. Objects and arrays are prettified
+// (or compacted), and scalars are printed as is, with strings
+// unquoted.
+func extract(file string, path []pathElem, shouldCompact, validateOnly bool) error {
+	var in []byte
+	var err error
+
+	if file == "-" {
+		in, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		in, err = ioutil.ReadFile(file)
+	}
+
+	if err != nil {
+		lib.Warn(err, "ReadFile")
+		return err
+	}
+
+	value, err := lookup(in, path)
+	if err != nil {
+		lib.Warn(err, "%s", file)
+		return err
+	}
+
+	var buf = &bytes.Buffer{}
+	switch {
+	case value[0] == '"':
+		var s string
+		err = json.Unmarshal(value, &s)
+		buf.WriteString(s)
+	case shouldCompact:
+		err = json.Compact(buf, value)
+	default:
+		err = json.Indent(buf, value, "", "    ")
+	}
+
+	if err != nil {
+		lib.Warn(err, "%s", file)
+		return err
+	}
+
+	if validateOnly {
+		return nil
+	}
+
+	buf.WriteByte('\n')
+	_, err = os.Stdout.Write(buf.Bytes())
+	return err
+}
+
 func usage() {
 	progname := lib.ProgName()
-	fmt.Printf(`Usage: %s [-h] files...
+	fmt.Printf(`Usage: %s [-h] [-p path] files...
 	%s is used to lint and prettify (or compact) JSON files. The
 	files will be updated in-place.
 
@@ -99,6 +230,9 @@ func usage() {
 	-c	Compact files.
 	-h	Print this help message.
 	-n	Don't prettify; only perform validation.
+	-p path	Print the value at path (e.g. foo.bar[0].name) to
+		standard output instead of updating the files. Strings
+		are printed unquoted.
 `, progname, progname)
 
 }
@@ -109,8 +243,10 @@ func init() {
 
 func main() {
 	var shouldCompact, validateOnly bool
+	var pathSpec string
 	flag.BoolVar(&shouldCompact, "c", false, "Compact files instead of prettifying.")
 	flag.BoolVar(&validateOnly, "n", false, "Don't write changes; only perform validation.")
+	flag.StringVar(&pathSpec, "p", "", "Print the value at `path` instead of updating files.")
 	flag.Parse()
 
 	action := prettify
@@ -118,6 +254,17 @@ func main() {
 		action = compact
 	}
 
+	if pathSpec != "" {
+		path, err := parsePath(pathSpec)
+		if err != nil {
+			lib.Err(lib.ExitFailure, err, "invalid path")
+		}
+
+		action = func(file string, validateOnly bool) error {
+			return extract(file, path, shouldCompact, validateOnly)
+		}
+	}
+
 	var errCount int
 	for _, fileName := range flag.Args() {
 		err := action(fileName, validateOnly)
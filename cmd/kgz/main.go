@@ -1,6 +1,7 @@
 package main
 
 import (
+	"archive/tar"
 	"compress/flate"
 	"compress/gzip"
 	"flag"
@@ -9,11 +10,25 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"git.wntrmute.dev/kyle/goutils/fileutil"
 	"github.com/pkg/errors"
 )
 
-const gzipExt = ".gz"
+const (
+	gzipExt    = ".gz"
+	tarGzipExt = ".tar.gz"
+)
+
+// maxExpansion limits how much larger than the compressed archive the
+// uncompressed tar stream may be, to guard against gzip bombs. Small
+// archives are always allowed up to minExpansionLimit bytes, since tar
+// padding alone can exceed the ratio.
+const (
+	maxExpansion      = 32
+	minExpansionLimit = 1 << 20
+)
 
 func compress(path, target string, level int) error {
 	sourceFile, err := os.Open(path)
@@ -73,8 +88,314 @@ func uncompress(path, target string) error {
 	return nil
 }
 
+// compressDir writes the directory tree at path to target as a
+// gzip-compressed tar archive. File modes and modification times are
+// recorded in the tar headers.
+func compressDir(path, target string, level int) error {
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return errors.Wrap(err, "resolving target path")
+	}
+
+	destFile, err := os.Create(target)
+	if err != nil {
+		return errors.Wrap(err, "opening file for write")
+	}
+
+	gzipCompressor, err := gzip.NewWriterLevel(destFile, level)
+	if err != nil {
+		destFile.Close()
+		return errors.Wrap(err, "invalid compression level")
+	}
+
+	tarWriter := tar.NewWriter(gzipCompressor)
+	err = writeTree(tarWriter, path, absTarget)
+	if err != nil {
+		tarWriter.Close()
+		gzipCompressor.Close()
+		destFile.Close()
+		return err
+	}
+
+	// The writers are closed explicitly, in order, so that a
+	// failure to flush the archive isn't reported as success.
+	if err = tarWriter.Close(); err != nil {
+		gzipCompressor.Close()
+		destFile.Close()
+		return errors.Wrap(err, "finishing tar archive")
+	}
+
+	if err = gzipCompressor.Close(); err != nil {
+		destFile.Close()
+		return errors.Wrap(err, "finishing gzip stream")
+	}
+
+	if err = destFile.Close(); err != nil {
+		return errors.Wrap(err, "closing archive")
+	}
+
+	return nil
+}
+
+// writeTree writes the directory tree at path to tarWriter, skipping
+// absTarget if it's inside the tree.
+func writeTree(tarWriter *tar.Writer, path, absTarget string) error {
+	root := filepath.Dir(filepath.Clean(path))
+	return filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Don't archive the archive if it's being written inside
+		// the source directory.
+		if absFile, err := filepath.Abs(file); err == nil && absFile == absTarget {
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(file)
+			if err != nil {
+				return errors.Wrap(err, "reading symlink")
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "skipping %s: not a regular file\n", file)
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return errors.Wrap(err, "building tar header")
+		}
+
+		name, err := filepath.Rel(root, file)
+		if err != nil {
+			return errors.Wrap(err, "building tar header")
+		}
+		hdr.Name = filepath.ToSlash(name)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err = tarWriter.WriteHeader(hdr); err != nil {
+			return errors.Wrap(err, "writing tar header")
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		sourceFile, err := os.Open(file)
+		if err != nil {
+			return errors.Wrap(err, "opening file for read")
+		}
+		defer sourceFile.Close()
+
+		_, err = io.Copy(tarWriter, sourceFile)
+		if err != nil {
+			return errors.Wrap(err, "compressing file")
+		}
+
+		return nil
+	})
+}
+
+// boundedReader fails once more than n bytes have been read from r.
+type boundedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (br *boundedReader) Read(p []byte) (int, error) {
+	n, err := br.r.Read(p)
+	br.n -= int64(n)
+	if br.n < 0 {
+		return n, errors.Errorf("uncompressed archive is more than %d times the size of the compressed archive", maxExpansion)
+	}
+
+	return n, err
+}
+
+// checkParents returns an error if any directory between top and
+// name (which is relative to top) is a symlink or not a directory, so
+// that extraction never writes through a symlink in the archive. If
+// name itself exists, it must not be a symlink either.
+func checkParents(top, name string) error {
+	cur := top
+	for _, part := range strings.Split(name, string(filepath.Separator)) {
+		cur = filepath.Join(cur, part)
+		info, err := os.Lstat(cur)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return errors.Errorf("refusing to extract through symlink %s", cur)
+		}
+
+		if cur != filepath.Join(top, name) && !info.IsDir() {
+			return errors.Errorf("%s is not a directory", cur)
+		}
+	}
+
+	return nil
+}
+
+// checkSymlink returns an error if the symlink name (relative to top)
+// pointing to linkname would refer to something outside of top.
+func checkSymlink(top, name, linkname string) error {
+	if filepath.IsAbs(linkname) || !filepath.IsLocal(filepath.Join(filepath.Dir(name), linkname)) {
+		return errors.Errorf("symlink %s -> %s points outside of %s", name, linkname, top)
+	}
+
+	return nil
+}
+
+// extractSymlink creates the symlink name (relative to top) pointing
+// to linkname. If the link can be resolved once it's been created, it
+// must resolve to somewhere inside top.
+func extractSymlink(top, name, linkname string) error {
+	if err := checkSymlink(top, name, linkname); err != nil {
+		return err
+	}
+
+	path := filepath.Join(top, name)
+	if err := os.Symlink(linkname, path); err != nil {
+		return err
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil || resolved == top {
+		return nil
+	}
+
+	if !fileutil.ValidateSymlink(path, top+string(filepath.Separator)) {
+		os.Remove(path)
+		return errors.Errorf("symlink %s -> %s points outside of %s", name, linkname, top)
+	}
+
+	return nil
+}
+
+type dirTime struct {
+	path    string
+	modTime time.Time
+}
+
+// uncompressDir extracts the gzip-compressed tar archive at path into
+// the directory target, restoring file modes and modification times.
+func uncompressDir(path, target string) error {
+	top, err := filepath.Abs(target)
+	if err != nil {
+		return errors.Wrap(err, "resolving target path")
+	}
+
+	top, err = filepath.EvalSymlinks(top)
+	if err != nil {
+		return errors.Wrap(err, "resolving target path")
+	}
+
+	sourceFile, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "opening file for read")
+	}
+	defer sourceFile.Close()
+
+	stat, err := sourceFile.Stat()
+	if err != nil {
+		return errors.Wrap(err, "stat(2)ing source file")
+	}
+
+	gzipUncompressor, err := gzip.NewReader(sourceFile)
+	if err != nil {
+		return errors.Wrap(err, "reading gzip headers")
+	}
+	defer gzipUncompressor.Close()
+
+	limit := maxExpansion * stat.Size()
+	if limit < minExpansionLimit {
+		limit = minExpansionLimit
+	}
+
+	tarReader := tar.NewReader(&boundedReader{r: gzipUncompressor, n: limit})
+
+	// Directory modification times are restored once everything
+	// has been extracted, since extracting into a directory
+	// updates its modification time.
+	var dirs []dirTime
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrap(err, "reading tar header")
+		}
+
+		name := filepath.FromSlash(hdr.Name)
+		if filepath.IsAbs(name) || !filepath.IsLocal(name) {
+			return errors.Errorf("refusing to extract %s outside of %s", hdr.Name, target)
+		}
+		name = filepath.Clean(name)
+
+		if err = checkParents(top, name); err != nil {
+			return errors.Wrapf(err, "extracting %s", hdr.Name)
+		}
+
+		fullName := filepath.Join(top, name)
+		mode := hdr.FileInfo().Mode()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(fullName, mode.Perm())
+			dirs = append(dirs, dirTime{path: fullName, modTime: hdr.ModTime})
+		case tar.TypeSymlink:
+			err = extractSymlink(top, name, hdr.Linkname)
+		case tar.TypeReg:
+			err = extractFile(tarReader, fullName, mode.Perm())
+			if err == nil {
+				err = os.Chtimes(fullName, hdr.ModTime, hdr.ModTime)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "skipping %s: not a regular file\n", hdr.Name)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "extracting %s", hdr.Name)
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err = os.Chtimes(dirs[i].path, dirs[i].modTime, dirs[i].modTime); err != nil {
+			return errors.Wrapf(err, "restoring modification time of %s", dirs[i].path)
+		}
+	}
+
+	return nil
+}
+
+func extractFile(r io.Reader, name string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	destFile, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return errors.Wrap(err, "opening file for write")
+	}
+	defer destFile.Close()
+
+	_, err = io.Copy(destFile, r)
+	if err != nil {
+		return errors.Wrap(err, "uncompressing file")
+	}
+
+	return nil
+}
+
 func usage(w io.Writer) {
-	fmt.Fprintf(w, `Usage: %s [-l] source [target]
+	fmt.Fprintf(w, `Usage: %s [-l] [-r] source [target]
 
 kgz is like gzip, but supports compressing and decompressing to a different
 directory than the source file is in.
@@ -82,6 +403,9 @@ directory than the source file is in.
 Flags:
 	-l level	Compression level (0-9). Only meaninful when
 			compressing a file.
+	-r		Compress a directory into a .tar.gz archive.
+			A .tar.gz archive is extracted into the target
+			if it's a directory; -r requires this.
 `, os.Args[0])
 }
 
@@ -125,21 +449,28 @@ func pathForCompressing(source, dest string) (string, error) {
 		return dest, nil
 	}
 
-	source = filepath.Base(source)
+	ext := gzipExt
+	if isDir(source) {
+		ext = tarGzipExt
+	}
+
+	source = filepath.Base(filepath.Clean(source))
 	if strings.HasSuffix(source, gzipExt) {
 		return "", errors.Errorf("%s is a gzip-compressed file", source)
 	}
 
-	dest = filepath.Join(dest, source+gzipExt)
+	dest = filepath.Join(dest, source+ext)
 	return dest, nil
 }
 
 func main() {
 	var level int
+	var recursive bool
 	var path string
 	var target = "."
 
 	flag.IntVar(&level, "l", flate.DefaultCompression, "compression level")
+	flag.BoolVar(&recursive, "r", false, "compress or extract a directory tree")
 	flag.Parse()
 
 	if flag.NArg() < 1 || flag.NArg() > 2 {
@@ -152,7 +483,18 @@ func main() {
 		target = flag.Arg(1)
 	}
 
-	if strings.HasSuffix(path, gzipExt) {
+	if strings.HasSuffix(path, tarGzipExt) && (recursive || isDir(target)) {
+		if !isDir(target) {
+			fmt.Fprintf(os.Stderr, "%s is not a directory\n", target)
+			os.Exit(1)
+		}
+
+		err := uncompressDir(path, target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+	} else if strings.HasSuffix(path, gzipExt) {
 		target, err := pathForUncompressing(path, target)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s\n", err)
@@ -172,7 +514,15 @@ func main() {
 			os.Exit(1)
 		}
 
-		err = compress(path, target, level)
+		if isDir(path) {
+			if !recursive {
+				fmt.Fprintf(os.Stderr, "%s is a directory; use -r to compress it\n", path)
+				os.Exit(1)
+			}
+			err = compressDir(path, target, level)
+		} else {
+			err = compress(path, target, level)
+		}
 		if err != nil {
 			os.Remove(target)
 			fmt.Fprintf(os.Stderr, "%s\n", err)
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "tree")
+	sub := filepath.Join(src, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"a.txt":     "hello, world\n",
+		"sub/b.txt": "goodbye, world\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(contents), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink("sub/b.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"a.txt", "sub/b.txt", "sub", "."} {
+		if err := os.Chtimes(filepath.Join(src, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive := filepath.Join(t.TempDir(), "tree.tar.gz")
+	if err := compressDir(src, archive, gzip.DefaultCompression); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := t.TempDir()
+	if err := uncompressDir(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, contents := range files {
+		path := filepath.Join(dest, "tree", name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != contents {
+			t.Fatalf("kgz: %s: expected %q, have %q", name, contents, data)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Mode().Perm() != 0640 {
+			t.Fatalf("kgz: %s: expected mode 0640, have %o", name, info.Mode().Perm())
+		}
+	}
+
+	link, err := os.Readlink(filepath.Join(dest, "tree", "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if link != "sub/b.txt" {
+		t.Fatalf("kgz: expected link to sub/b.txt, have %s", link)
+	}
+
+	for _, name := range []string{"a.txt", "sub/b.txt", "sub", "."} {
+		info, err := os.Stat(filepath.Join(dest, "tree", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !info.ModTime().Equal(mtime) {
+			t.Fatalf("kgz: %s: expected mtime %s, have %s", name, mtime, info.ModTime())
+		}
+	}
+}
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	contents string
+}
+
+func writeArchive(t *testing.T, entries []tarEntry) string {
+	archive := filepath.Join(t.TempDir(), "evil.tar.gz")
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gzw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gzw)
+	for _, entry := range entries {
+		hdr := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     0644,
+			Size:     int64(len(entry.contents)),
+		}
+		if entry.typeflag == tar.TypeDir {
+			hdr.Mode = 0755
+		}
+
+		if err = tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = tw.Write([]byte(entry.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return archive
+}
+
+func TestUncompressDirMalicious(t *testing.T) {
+	outside := t.TempDir()
+
+	tests := map[string][]tarEntry{
+		"absolute symlink": {
+			{name: "link", typeflag: tar.TypeSymlink, linkname: outside},
+			{name: "link/passwd", typeflag: tar.TypeReg, contents: "pwned"},
+		},
+		"relative symlink": {
+			{name: "link", typeflag: tar.TypeSymlink, linkname: "../../../../../../../../" + outside},
+			{name: "link/passwd", typeflag: tar.TypeReg, contents: "pwned"},
+		},
+		"write through symlink": {
+			{name: "dir/", typeflag: tar.TypeDir},
+			{name: "link", typeflag: tar.TypeSymlink, linkname: "dir"},
+			{name: "link/passwd", typeflag: tar.TypeReg, contents: "pwned"},
+		},
+		"overwrite symlink": {
+			{name: "dir/", typeflag: tar.TypeDir},
+			{name: "link", typeflag: tar.TypeSymlink, linkname: "dir/passwd"},
+			{name: "link", typeflag: tar.TypeReg, contents: "pwned"},
+		},
+		"parent path": {
+			{name: "../passwd", typeflag: tar.TypeReg, contents: "pwned"},
+		},
+	}
+
+	for name, entries := range tests {
+		archive := writeArchive(t, entries)
+		dest := t.TempDir()
+		if err := uncompressDir(archive, dest); err == nil {
+			t.Fatalf("kgz: %s: expected extraction to fail", name)
+		}
+
+		for _, path := range []string{filepath.Join(outside, "passwd"), filepath.Join(dest, "dir", "passwd")} {
+			if _, err := os.Lstat(path); err == nil {
+				t.Fatalf("kgz: %s: %s should not have been written", name, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"git.wntrmute.dev/kyle/goutils/ahash"
+	"git.wntrmute.dev/kyle/goutils/die"
+	"git.wntrmute.dev/kyle/goutils/lib"
+)
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, `Usage: %s [-a algo] [-h] [-j N] files...
+       %s [-a algo] [-j N] -c sumfile
+Compute the hash of each file, printing the results in the same
+format as sha256sum.
+
+Flags:
+	-a algo		Specify the hash algorithm to use; the default is sha256.
+	-c sumfile	Check the files listed in sumfile, which is in the
+			format produced by this program or sha256sum.
+	-h		Print this help message.
+	-j N		Hash up to N files at once; the default is the
+			number of CPUs.
+`, lib.ProgName(), lib.ProgName())
+}
+
+func init() {
+	flag.Usage = func() { usage(os.Stderr) }
+}
+
+func check(algo string, jobs int, sumFile string) {
+	file, err := os.Open(sumFile)
+	die.If(err)
+	defer file.Close()
+
+	expected, err := ahash.ParseHashFile(file)
+	die.IfMsg(err, "reading %s", sumFile)
+
+	paths := make([]string, 0, len(expected))
+	for _, entry := range expected {
+		paths = append(paths, entry.Path)
+	}
+
+	results, err := ahash.SumFiles(algo, jobs, paths)
+	die.If(err)
+
+	failed := 0
+	for i, result := range results {
+		switch {
+		case result.Err != nil:
+			lib.Warn(result.Err, "hashing %s", result.Path)
+			fmt.Printf("%s: FAILED open or read\n", result.Path)
+			failed++
+		case !bytes.Equal(result.Sum, expected[i].Sum):
+			fmt.Printf("%s: FAILED\n", result.Path)
+			failed++
+		default:
+			fmt.Printf("%s: OK\n", result.Path)
+		}
+	}
+
+	if failed > 0 {
+		lib.Errx(lib.ExitFailure, "WARNING: %d of %d computed checksums did NOT match", failed, len(results))
+	}
+}
+
+func main() {
+	var algo, sumFile string
+	var help bool
+	var jobs int
+	flag.StringVar(&algo, "a", "sha256", "hash algorithm to use")
+	flag.StringVar(&sumFile, "c", "", "check the files listed in `sumfile`")
+	flag.BoolVar(&help, "h", false, "print a help message")
+	flag.IntVar(&jobs, "j", runtime.NumCPU(), "hash up to `N` files at once")
+	flag.Parse()
+
+	if help {
+		usage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if sumFile != "" {
+		check(algo, jobs, sumFile)
+		return
+	}
+
+	if flag.NArg() == 0 {
+		usage(os.Stderr)
+		os.Exit(lib.ExitFailure)
+	}
+
+	results, err := ahash.SumFiles(algo, jobs, flag.Args())
+	die.If(err)
+
+	status := lib.ExitSuccess
+	for _, result := range results {
+		if result.Err != nil {
+			lib.Warn(result.Err, "hashing %s", result.Path)
+			status = lib.ExitFailure
+		}
+	}
+
+	fmt.Print(ahash.FormatSHA256Sum(results))
+	os.Exit(status)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"git.wntrmute.dev/kyle/goutils/certlib/dump"
+	"git.wntrmute.dev/kyle/goutils/lib"
+)
+
+func main() {
+	var password string
+	flag.StringVar(&password, "p", "", "`password` for the PKCS #12 files")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		lib.Errx(lib.ExitFailure, "Usage: %s [-p password] file.p12...", lib.ProgName())
+	}
+
+	exitCode := lib.ExitSuccess
+	for _, path := range flag.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			lib.Warn(err, "couldn't read %s", path)
+			exitCode = lib.ExitFailure
+			continue
+		}
+
+		fmt.Printf("--%s ---\n", path)
+		err = dump.DumpPKCS12(os.Stdout, data, password)
+		if err != nil {
+			lib.Warn(err, "couldn't dump %s", path)
+			exitCode = lib.ExitFailure
+		}
+	}
+
+	os.Exit(exitCode)
+}
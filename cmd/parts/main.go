@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"git.wntrmute.dev/kyle/goutils/die"
+	"git.wntrmute.dev/kyle/goutils/fileutil"
 )
 
 const dbVersion = "1"
@@ -74,7 +75,7 @@ func writeDB() {
 	data, err := json.Marshal(partsDB)
 	die.If(err)
 
-	err = ioutil.WriteFile(dbFile, data, 0644)
+	err = fileutil.AtomicWriteFile(dbFile, data, 0644)
 	die.If(err)
 }
 
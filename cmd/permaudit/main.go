@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"git.wntrmute.dev/kyle/goutils/die"
+	"git.wntrmute.dev/kyle/goutils/fileutil"
+	"git.wntrmute.dev/kyle/goutils/lib"
+)
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, `Usage: %s [-a] [-d mode] [-f mode] [-h] [-j] [-s paths] dir...
+Report files and directories that violate a permissions policy. By
+default, world-readable and world-writable files and directories, and
+setuid or setgid files, are reported.
+
+Flags:
+	-a		Also report files that can't be read and
+			directories that can't be searched.
+	-d mode		The most permissive octal mode allowed for
+			directories (default 0771).
+	-f mode		The most permissive octal mode allowed for
+			regular files (default 0771).
+	-h		Print this help message.
+	-j		Print the violations as JSON.
+	-s paths	A comma-separated list of paths that are allowed
+			to be setuid or setgid.
+`, lib.ProgName())
+}
+
+func init() {
+	flag.Usage = func() { usage(os.Stderr) }
+}
+
+type violation struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Rule string `json:"rule"`
+}
+
+func parseMode(s string) os.FileMode {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	die.IfMsg(err, "invalid mode %s", s)
+
+	return os.FileMode(mode).Perm()
+}
+
+func main() {
+	var access, help, jsonOutput bool
+	var dirMode, fileMode, setuidAllow string
+	flag.BoolVar(&access, "a", false, "report unreadable files and unsearchable directories")
+	flag.StringVar(&dirMode, "d", "0771", "most permissive `mode` allowed for directories")
+	flag.StringVar(&fileMode, "f", "0771", "most permissive `mode` allowed for regular files")
+	flag.BoolVar(&help, "h", false, "print a help message")
+	flag.BoolVar(&jsonOutput, "j", false, "print violations as JSON")
+	flag.StringVar(&setuidAllow, "s", "", "comma-separated `paths` allowed to be setuid or setgid")
+	flag.Parse()
+
+	if help {
+		usage(os.Stdout)
+		os.Exit(lib.ExitSuccess)
+	}
+
+	if flag.NArg() == 0 {
+		usage(os.Stderr)
+		os.Exit(lib.ExitFailure)
+	}
+
+	policy := fileutil.StrictPolicy()
+	policy.MaxDirPerm = parseMode(dirMode)
+	policy.MaxFilePerm = parseMode(fileMode)
+	policy.RequireAccess = access
+	if setuidAllow != "" {
+		policy.SetuidAllowlist = strings.Split(setuidAllow, ",")
+	}
+
+	violations := []violation{}
+	for _, root := range flag.Args() {
+		found, err := fileutil.AuditPermissions(root, policy)
+		if err != nil {
+			lib.Err(lib.ExitFailure, err, "auditing %s", root)
+		}
+
+		for _, v := range found {
+			violations = append(violations, violation{
+				Path: v.Path,
+				Mode: v.Mode.String(),
+				Rule: v.Rule,
+			})
+		}
+	}
+
+	if jsonOutput {
+		out, err := json.MarshalIndent(violations, "", "  ")
+		die.If(err)
+		fmt.Println(string(out))
+	} else {
+		for _, v := range violations {
+			fmt.Printf("%s (%s): %s\n", v.Path, v.Mode, v.Rule)
+		}
+	}
+
+	if len(violations) > 0 {
+		os.Exit(lib.ExitFailure)
+	}
+}
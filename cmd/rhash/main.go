@@ -15,7 +15,7 @@ import (
 )
 
 func usage(w io.Writer) {
-	fmt.Fprintf(w, `Usage: %s [-a algo] [-h] [-l set] urls...
+	fmt.Fprintf(w, `Usage: %s [-a algo] [-h] [-l set] [-r rps] urls...
 Compute the hash over each URL.
 
 Flags:
@@ -24,6 +24,7 @@ Flags:
 	-l set		List the hash functions under set. Set can be one of all,
 			secure to list only cryptographic hash functions, or
 			insecure to list only non-cryptographic hash functions.
+	-r rps		Limit requests to rps requests per second.
 	
 `, lib.ProgName())
 }
@@ -35,9 +36,11 @@ func init() {
 func main() {
 	var algo, list string
 	var help bool
+	var rps float64
 	flag.StringVar(&algo, "a", "sha256", "hash algorithm to use")
 	flag.BoolVar(&help, "h", false, "print a help message")
 	flag.StringVar(&list, "l", "", "list known hash algorithms (one of all, secure, insecure)")
+	flag.Float64Var(&rps, "r", 0, "limit requests to `rps` requests per second")
 	flag.Parse()
 
 	if help {
@@ -63,6 +66,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	client := http.DefaultClient
+	if rps > 0 {
+		var err error
+		client, err = lib.NewRateLimitedHTTPClient(rps, lib.DialerOpts{})
+		die.If(err)
+	}
+
 	for _, remote := range flag.Args() {
 		u, err := url.Parse(remote)
 		if err != nil {
@@ -76,7 +86,7 @@ func main() {
 			continue
 		}
 
-		resp, err := http.Get(remote)
+		resp, err := client.Get(remote)
 		if err != nil {
 			lib.Warn(err, "fetching %s", remote)
 			continue
@@ -4,19 +4,17 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
-	"crypto/sha1"
 	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/asn1"
 	"encoding/pem"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
-	"strings"
 
+	"git.wntrmute.dev/kyle/goutils/certlib/ski"
 	"git.wntrmute.dev/kyle/goutils/die"
 	"git.wntrmute.dev/kyle/goutils/lib"
 )
@@ -39,7 +37,7 @@ func init() {
 	flag.Usage = func() { usage(os.Stderr) }
 }
 
-func parse(path string) (public []byte, kt, ft string) {
+func parse(path string) (public crypto.PublicKey, kt, ft string) {
 	data, err := ioutil.ReadFile(path)
 	die.If(err)
 
@@ -57,22 +55,41 @@ func parse(path string) (public []byte, kt, ft string) {
 
 	switch p.Type {
 	case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY":
-		public, kt = parseKey(data)
+		public = parseKey(data)
 		ft = "private key"
+	case "PUBLIC KEY", "RSA PUBLIC KEY":
+		public = parsePublicKey(data)
+		ft = "public key"
 	case "CERTIFICATE":
-		public, kt = parseCertificate(data)
+		public = parseCertificate(data)
 		ft = "certificate"
 	case "CERTIFICATE REQUEST":
-		public, kt = parseCSR(data)
+		public = parseCSR(data)
 		ft = "certificate request"
 	default:
 		die.With("unknown PEM type %s", p.Type)
 	}
 
+	kt = keyType(public)
 	return
 }
 
-func parseKey(data []byte) (public []byte, kt string) {
+func keyType(pub crypto.PublicKey) string {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return "RSA"
+	case *ecdsa.PublicKey:
+		return "ECDSA"
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		die.With("unknown public key type %T", pub)
+	}
+
+	return ""
+}
+
+func parseKey(data []byte) crypto.PublicKey {
 	privInterface, err := x509.ParsePKCS8PrivateKey(data)
 	if err != nil {
 		privInterface, err = x509.ParsePKCS1PrivateKey(data)
@@ -84,74 +101,36 @@ func parseKey(data []byte) (public []byte, kt string) {
 		}
 	}
 
-	var priv crypto.Signer
-	switch privInterface.(type) {
-	case *rsa.PrivateKey:
-		priv = privInterface.(*rsa.PrivateKey)
-		kt = "RSA"
-	case *ecdsa.PrivateKey:
-		priv = privInterface.(*ecdsa.PrivateKey)
-		kt = "ECDSA"
-	default:
+	priv, ok := privInterface.(crypto.Signer)
+	if !ok {
 		die.With("unknown private key type %T", privInterface)
 	}
 
-	public, err = x509.MarshalPKIXPublicKey(priv.Public())
-	die.If(err)
-
-	return
+	return priv.Public()
 }
 
-func parseCertificate(data []byte) (public []byte, kt string) {
-	cert, err := x509.ParseCertificate(data)
-	die.If(err)
-
-	pub := cert.PublicKey
-	switch pub.(type) {
-	case *rsa.PublicKey:
-		kt = "RSA"
-	case *ecdsa.PublicKey:
-		kt = "ECDSA"
-	default:
-		die.With("unknown public key type %T", pub)
+func parsePublicKey(data []byte) crypto.PublicKey {
+	pub, err := x509.ParsePKIXPublicKey(data)
+	if err != nil {
+		pub, err = x509.ParsePKCS1PublicKey(data)
+		if err != nil {
+			die.With("couldn't parse public key.")
+		}
 	}
 
-	public, err = x509.MarshalPKIXPublicKey(pub)
-	die.If(err)
-	return
+	return pub
 }
 
-func parseCSR(data []byte) (public []byte, kt string) {
-	csr, err := x509.ParseCertificateRequest(data)
-	die.If(err)
-
-	pub := csr.PublicKey
-	switch pub.(type) {
-	case *rsa.PublicKey:
-		kt = "RSA"
-	case *ecdsa.PublicKey:
-		kt = "ECDSA"
-	default:
-		die.With("unknown public key type %T", pub)
-	}
-
-	public, err = x509.MarshalPKIXPublicKey(pub)
+func parseCertificate(data []byte) crypto.PublicKey {
+	cert, err := x509.ParseCertificate(data)
 	die.If(err)
-	return
+	return cert.PublicKey
 }
 
-func dumpHex(in []byte) string {
-	var s string
-	for i := range in {
-		s += fmt.Sprintf("%02X:", in[i])
-	}
-
-	return strings.Trim(s, ":")
-}
-
-type subjectPublicKeyInfo struct {
-	Algorithm        pkix.AlgorithmIdentifier
-	SubjectPublicKey asn1.BitString
+func parseCSR(data []byte) crypto.PublicKey {
+	csr, err := x509.ParseCertificateRequest(data)
+	die.If(err)
+	return csr.PublicKey
 }
 
 func main() {
@@ -165,26 +144,24 @@ func main() {
 		os.Exit(0)
 	}
 
-	var ski string
+	var firstSKI string
 	for _, path := range flag.Args() {
 		public, kt, ft := parse(path)
 
-		var subPKI subjectPublicKeyInfo
-		_, err := asn1.Unmarshal(public, &subPKI)
+		pubHash, err := ski.ComputeSKI(public)
 		if err != nil {
-			lib.Warn(err, "failed to get subject PKI")
+			lib.Warn(err, "failed to compute SKI")
 			continue
 		}
 
-		pubHash := sha1.Sum(subPKI.SubjectPublicKey.Bytes)
-		pubHashString := dumpHex(pubHash[:])
-		if ski == "" {
-			ski = pubHashString
+		pubHashString := ski.FormatSKI(pubHash, lib.HexEncodeUpperColon)
+		if firstSKI == "" {
+			firstSKI = pubHashString
 		}
 
-		if shouldMatch && ski != pubHashString {
+		if shouldMatch && firstSKI != pubHashString {
 			lib.Warnx("%s: SKI mismatch (%s != %s)",
-				path, ski, pubHashString)
+				path, firstSKI, pubHashString)
 		}
 		fmt.Printf("%s  %s (%s %s)\n", path, pubHashString, kt, ft)
 	}
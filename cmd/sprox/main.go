@@ -1,12 +1,15 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"io"
 	"log"
 	"net"
 
+	"git.wntrmute.dev/kyle/goutils/certlib"
 	"git.wntrmute.dev/kyle/goutils/die"
+	"git.wntrmute.dev/kyle/goutils/lib"
 )
 
 func proxy(conn net.Conn, inside string) error {
@@ -25,15 +28,61 @@ func proxy(conn net.Conn, inside string) error {
 	return err
 }
 
+// tlsConfig builds the server configuration used to terminate TLS on
+// the outside face. certFile may hold the full chain, which is served
+// to clients, and the key in keyFile must match its leaf. If caFile is
+// not empty, clients must present a certificate that verifies against
+// it.
+func tlsConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := lib.BaselineTLSConfig(true)
+	cfg.Certificates = []tls.Certificate{cert}
+
+	if caFile != "" {
+		pool, err := certlib.LoadPEMCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
 func main() {
 	var outside, inside string
+	var certFile, keyFile, caFile string
 	flag.StringVar(&outside, "f", "8080", "outside port")
 	flag.StringVar(&inside, "p", "4000", "inside port")
+	flag.StringVar(&certFile, "tls-cert", "", "certificate for terminating TLS on the outside port")
+	flag.StringVar(&keyFile, "tls-key", "", "private key for the TLS certificate")
+	flag.StringVar(&caFile, "tls-ca", "", "CA bundle used to verify client certificates")
 	flag.Parse()
 
+	if (certFile == "") != (keyFile == "") {
+		lib.Errx(lib.ExitFailure, "both -tls-cert and -tls-key must be specified")
+	}
+
+	if caFile != "" && certFile == "" {
+		lib.Errx(lib.ExitFailure, "-tls-ca requires -tls-cert and -tls-key")
+	}
+
 	l, err := net.Listen("tcp", "0.0.0.0:"+outside)
 	die.If(err)
 
+	if certFile != "" {
+		cfg, err := tlsConfig(certFile, keyFile, caFile)
+		die.If(err)
+
+		l = tls.NewListener(l, cfg)
+	}
+
 	for {
 		conn, err := l.Accept()
 		if err != nil {
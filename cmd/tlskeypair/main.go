@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
@@ -12,9 +13,9 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 
+	"git.wntrmute.dev/kyle/goutils/certlib"
 	"git.wntrmute.dev/kyle/goutils/die"
 )
 
@@ -83,6 +84,8 @@ func loadKey(path string) (crypto.Signer, error) {
 		return priv.(*rsa.PrivateKey), nil
 	case *ecdsa.PrivateKey:
 		return priv.(*ecdsa.PrivateKey), nil
+	case ed25519.PrivateKey:
+		return priv.(ed25519.PrivateKey), nil
 	}
 
 	// should never reach here
@@ -97,7 +100,7 @@ func main() {
 	flag.Parse()
 
 	in, err := ioutil.ReadFile(certFile)
-	die.If(err)
+	die.IfMsg(err, "reading certificate %s", certFile)
 
 	p, _ := pem.Decode(in)
 	if p != nil {
@@ -107,55 +110,35 @@ func main() {
 		in = p.Bytes
 	}
 	cert, err := x509.ParseCertificate(in)
-	die.If(err)
+	die.IfMsg(err, "parsing certificate %s", certFile)
 
 	priv, err := loadKey(keyFile)
-	die.If(err)
+	die.IfMsg(err, "loading private key %s", keyFile)
 
-	switch pub := priv.Public().(type) {
-	case *rsa.PublicKey:
-		switch certPub := cert.PublicKey.(type) {
-		case *rsa.PublicKey:
-			if pub.N.Cmp(certPub.N) != 0 || pub.E != certPub.E {
-				fmt.Println("No match (public keys don't match).")
-				os.Exit(1)
-			}
-			fmt.Println("Match.")
-			return
-		case *ecdsa.PublicKey:
-			fmt.Println("No match (RSA private key, EC public key).")
-			os.Exit(1)
-		}
-	case *ecdsa.PublicKey:
-		privCurve := getECCurve(pub)
-		certCurve := getECCurve(cert.PublicKey)
-		log.Printf("priv: %d\tcert: %d\n", privCurve, certCurve)
-
-		if certCurve == curveRSA {
-			fmt.Println("No match (private key is EC, certificate is RSA).")
-			os.Exit(1)
-		} else if privCurve == curveInvalid {
-			fmt.Println("No match (invalid private key curve).")
-			os.Exit(1)
-		} else if privCurve != certCurve {
-			fmt.Println("No match (EC curves don't match).")
-			os.Exit(1)
-		}
+	privAlgo := certlib.KeyAlgorithm(priv.Public())
+	certAlgo := certlib.KeyAlgorithm(cert.PublicKey)
+	fmt.Printf("Private key: %s\n", privAlgo)
+	fmt.Printf("Certificate: %s\n", certAlgo)
 
-		certPub := cert.PublicKey.(*ecdsa.PublicKey)
-		if pub.X.Cmp(certPub.X) != 0 {
-			fmt.Println("No match (public keys don't match).")
-			os.Exit(1)
-		}
+	match, err := certlib.MatchKeys(cert, priv)
+	if err != nil {
+		fmt.Printf("Unrecognised private key type: %T\n", priv.Public())
+		os.Exit(1)
+	}
 
-		if pub.Y.Cmp(certPub.Y) != 0 {
+	if !match {
+		privCurve := getECCurve(priv.Public())
+		certCurve := getECCurve(cert.PublicKey)
+		switch {
+		case privAlgo != certAlgo && privCurve > curveRSA && certCurve > curveRSA:
+			fmt.Println("No match (EC curves don't match).")
+		case privAlgo != certAlgo:
+			fmt.Printf("No match (%s private key, %s public key).\n", privAlgo, certAlgo)
+		default:
 			fmt.Println("No match (public keys don't match).")
-			os.Exit(1)
 		}
-
-		fmt.Println("Match.")
-	default:
-		fmt.Printf("Unrecognised private key type: %T\n", priv.Public())
 		os.Exit(1)
 	}
+
+	fmt.Println("Match.")
 }
@@ -16,11 +16,12 @@ var (
 	tz        = "Local"            // String descriptor for timezone.
 	fromLoc   = time.Local         // Go time.Location for the named timezone.
 	fromUnix  bool                 // Input times are Unix timestamps.
+	toUnix    bool                 // Print times as Unix timestamps.
 	toLoc     = time.UTC           // Go time.Location for output timezone.
 )
 
 func usage(w io.Writer) {
-	fmt.Fprintf(w, `Usage:	utc [-f format] [-o format] [-q] [-t] [-u] [-z zone] [time(s)...]
+	fmt.Fprintf(w, `Usage:	utc [-e] [-E] [-f format] [-o format] [-q] [-t] [-u] [-z zone] [time(s)...]
 	utc -h | utc help
 
 
@@ -34,6 +35,13 @@ be suppressed with the -q option.
 
 Flags:
 
+	-e		Input times are Unix timestamps; this is the same
+			as -t.
+
+	-E		Print each time as a Unix timestamp (seconds since
+			1970-01-01 00:00:00 UTC) instead of formatting it.
+			The timezone check warning isn't printed.
+
 	-f format	Go timestamp format for input times. See the Go docs
 			(e.g. https://golang.org/pkg/time/#pkg-constants)
 			for an explanation of this format.
@@ -112,6 +120,9 @@ PST8PDT time zone):
 	+ Converting a Unix timestamp to EST:
 	  $ utc -t -u -z EST 1466052938
 	  2016-06-16 04:55 UTC = 2016-06-15 23:55 EST
+	+ Converting a local timestamp to a Unix timestamp:
+	  $ utc -E '2016-06-16 04:55'
+	  1466078100
 	+ Example of the warning message when running utc on a machine
 	  where the local time zone is UTC:
 	  $ utc
@@ -166,6 +177,8 @@ func init() {
 	var help, quiet, utc bool
 
 	flag.Usage = func() { usage(os.Stderr) }
+	flag.BoolVar(&fromUnix, "e", false, "input times are Unix timestamps")
+	flag.BoolVar(&toUnix, "E", false, "print times as Unix timestamps")
 	flag.StringVar(&format, "f", format, "time format")
 	flag.BoolVar(&help, "h", false, "print usage information")
 	flag.StringVar(&outFormat, "o", outFormat, "output time format")
@@ -208,10 +221,15 @@ func init() {
 		toLoc = time.UTC
 	}
 
-	checkZones(quiet)
+	checkZones(quiet || toUnix)
 }
 
 func showTime(t time.Time) {
+	if toUnix {
+		fmt.Println(t.Unix())
+		return
+	}
+
 	fmt.Printf("%s = %s\n", t.Format(outFormat),
 		t.In(toLoc).Format(outFormat))
 }
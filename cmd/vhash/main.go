@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"git.wntrmute.dev/kyle/goutils/ahash"
+	"git.wntrmute.dev/kyle/goutils/lib"
+)
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, `Usage: %s [-d dir] [-h] [-q] hashfile...
+Verify the SHA-256 digests of the files listed in each hashfile, which
+is in the format produced by sha256sum or mhash. Only files that don't
+match are reported; the exit status is 0 only if every file matches.
+
+Flags:
+	-d dir		Look for files with relative paths under dir; the
+			default is the current directory.
+	-h		Print this help message.
+	-q		Don't print anything; only set the exit status.
+`, lib.ProgName())
+}
+
+func init() {
+	flag.Usage = func() { usage(os.Stderr) }
+}
+
+func main() {
+	var basedir string
+	var help, quiet bool
+	flag.StringVar(&basedir, "d", ".", "look for files under `dir`")
+	flag.BoolVar(&help, "h", false, "print a help message")
+	flag.BoolVar(&quiet, "q", false, "only set the exit status")
+	flag.Parse()
+
+	if help {
+		usage(os.Stdout)
+		os.Exit(lib.ExitSuccess)
+	}
+
+	if flag.NArg() == 0 {
+		usage(os.Stderr)
+		os.Exit(lib.ExitFailure)
+	}
+
+	status := lib.ExitSuccess
+	for _, hashFile := range flag.Args() {
+		mismatches, err := ahash.VerifyHashFile(hashFile, basedir)
+		if err != nil {
+			lib.Warn(err, "verifying %s", hashFile)
+			status = lib.ExitFailure
+			continue
+		}
+
+		if len(mismatches) > 0 {
+			status = lib.ExitFailure
+		}
+
+		if quiet {
+			continue
+		}
+
+		for _, m := range mismatches {
+			if m.Err != nil {
+				fmt.Printf("%s: %v\n", m.Path, m.Err)
+			} else {
+				fmt.Printf("%s: expected %x, have %x\n", m.Path, m.Expected, m.Actual)
+			}
+		}
+	}
+
+	os.Exit(status)
+}
@@ -2,6 +2,10 @@
 // search string. It was really designed for use with the Git object
 // store, i.e. to aid in the recovery of files after Git does what Git
 // do.
+//
+// The -s flag may be given more than once; by default (-or), a file
+// is printed if any of the patterns match, and with -and, only if all
+// of them do.
 package main
 
 import (
@@ -14,6 +18,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 const defaultDirectory = ".git/objects"
@@ -65,7 +70,49 @@ func showFile(path string) {
 	fmt.Printf("%s\n", fileData)
 }
 
-func searchFile(path string, search *regexp.Regexp) error {
+// patternList collects the -s flags.
+type patternList []string
+
+func (p *patternList) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *patternList) Set(s string) error {
+	*p = append(*p, s)
+	return nil
+}
+
+// matcher checks file contents against a set of patterns; if all is
+// true, every pattern must match, otherwise any one of them may.
+type matcher struct {
+	patterns []*regexp.Regexp
+	all      bool
+}
+
+func (m *matcher) match(r io.Reader) (bool, error) {
+	// A single pattern can be matched as the file is
+	// decompressed; otherwise, each pattern needs to see the
+	// whole file.
+	if len(m.patterns) == 1 {
+		return m.patterns[0].MatchReader(bufio.NewReader(r)), nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return false, err
+	}
+
+	for _, pattern := range m.patterns {
+		matched := pattern.Match(data)
+		if matched != m.all {
+			return matched, nil
+		}
+	}
+
+	return m.all, nil
+}
+
+func searchFile(path string, search *matcher) error {
 	file, err := os.Open(path)
 	if err != nil {
 		errorf("%v", err)
@@ -80,8 +127,15 @@ func searchFile(path string, search *regexp.Regexp) error {
 	}
 	defer zread.Close()
 
-	zbuf := bufio.NewReader(zread)
-	if search.MatchReader(zbuf) {
+	// A corrupt object shouldn't stop the rest of the search, as
+	// it doesn't when there's only one pattern.
+	matched, err := search.match(zread)
+	if err != nil {
+		errorf("%s: %v", path, err)
+		return nil
+	}
+
+	if matched {
 		fileData, err := loadFile(path)
 		if err != nil {
 			errorf("%v", err)
@@ -92,7 +146,7 @@ func searchFile(path string, search *regexp.Regexp) error {
 	return nil
 }
 
-func buildWalker(searchExpr *regexp.Regexp) filepath.WalkFunc {
+func buildWalker(searchExpr *matcher) filepath.WalkFunc {
 	return func(path string, info os.FileInfo, err error) error {
 		if info.Mode().IsRegular() {
 			return searchFile(path, searchExpr)
@@ -102,18 +156,30 @@ func buildWalker(searchExpr *regexp.Regexp) filepath.WalkFunc {
 }
 
 func main() {
-	flSearch := flag.String("s", "", "search string (should be an RE2 regular expression)")
+	var flSearch patternList
+	flag.Var(&flSearch, "s", "search string (should be an RE2 regular expression); may be repeated")
+	flAnd := flag.Bool("and", false, "only print files that match every search string")
+	flOr := flag.Bool("or", false, "print files that match any search string (the default)")
 	flag.Parse()
 
-	if *flSearch == "" {
+	if *flAnd && *flOr {
+		errorf("Only one of -and and -or may be given.")
+		os.Exit(1)
+	}
+
+	if len(flSearch) == 0 {
 		for _, path := range flag.Args() {
 			showFile(path)
 		}
 	} else {
-		search, err := regexp.Compile(*flSearch)
-		if err != nil {
-			errorf("Bad regexp: %v", err)
-			return
+		search := &matcher{all: *flAnd}
+		for _, expr := range flSearch {
+			pattern, err := regexp.Compile(expr)
+			if err != nil {
+				errorf("Bad regexp: %v", err)
+				return
+			}
+			search.patterns = append(search.patterns, pattern)
 		}
 
 		pathList := flag.Args()
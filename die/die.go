@@ -4,6 +4,8 @@ package die
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 )
 
 // If prints the error to stderr and exits if err != nil.
@@ -14,6 +16,21 @@ func If(err error) {
 	}
 }
 
+// IfMsg is like If, but prefixes the error with the message and the
+// file and line of the caller, which helps find the call site that
+// failed.
+func IfMsg(err error, msg string, args ...interface{}) {
+	if err != nil {
+		where := "???"
+		if _, file, line, ok := runtime.Caller(1); ok {
+			where = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+		}
+
+		fmt.Fprintf(os.Stderr, "[!] %s: %s: %v\n", where, fmt.Sprintf(msg, args...), err)
+		os.Exit(1)
+	}
+}
+
 // With prints the message to stderr, appending a newline, and exits.
 func With(fstr string, args ...interface{}) {
 	out := fmt.Sprintf("[!] %s\n", fstr)
@@ -0,0 +1,194 @@
+package fileutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// writeTemp writes the contents of r to a new temporary file in dir
+// and syncs it to disk, returning the file's name. On error, the
+// temporary file is removed.
+func writeTemp(dir, base string, r io.Reader, perm os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+
+	name := tmp.Name()
+	fail := func(err error) (string, error) {
+		tmp.Close()
+		os.Remove(name)
+		return "", err
+	}
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		return fail(err)
+	}
+
+	if err = tmp.Chmod(perm); err != nil {
+		return fail(err)
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return fail(err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(name)
+		return "", err
+	}
+
+	return name, nil
+}
+
+// AtomicWriteFile writes data to path with the given permissions,
+// like os.WriteFile, but the data is written to a temporary file in
+// the same directory and synced before it is renamed over path. If
+// the process dies partway through, path holds either the old
+// contents or the new ones, never a partial write.
+func AtomicWriteFile(path string, data []byte, perm os.FileMode) error {
+	name, err := writeTemp(filepath.Dir(path), filepath.Base(path), bytes.NewReader(data), perm)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(name, path); err != nil {
+		os.Remove(name)
+		return err
+	}
+
+	return nil
+}
+
+// backup makes path + ".bak" a copy of path, replacing any previous
+// backup. The backup is a hard link where the filesystem supports
+// them, and a copy otherwise; path itself is never moved, so it
+// always exists while the backup is made.
+func backup(path string) error {
+	bak := path + ".bak"
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Link(path, bak); err == nil {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	name, err := writeTemp(filepath.Dir(bak), filepath.Base(bak), file, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(name, bak); err != nil {
+		os.Remove(name)
+		return err
+	}
+
+	return nil
+}
+
+// syncDir syncs the directory dir, so that renames into it survive a
+// crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	if err = d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+
+	return d.Close()
+}
+
+// AtomicWriteFileWithBackup is like AtomicWriteFile, but if path
+// already exists, it is first backed up to path + ".bak" (replacing
+// any previous backup). path is replaced by the new file in a single
+// rename, so it is never missing, and the directory is synced
+// afterwards so that the new file and its backup are both durable.
+func AtomicWriteFileWithBackup(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	name, err := writeTemp(dir, filepath.Base(path), bytes.NewReader(data), perm)
+	if err != nil {
+		return err
+	}
+
+	if FileDoesExist(path) {
+		if err = backup(path); err != nil {
+			os.Remove(name)
+			return err
+		}
+	}
+
+	if err = os.Rename(name, path); err != nil {
+		os.Remove(name)
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// SafeRename renames src to dst like os.Rename. If they're on
+// different filesystems, so that they can't be renamed directly, src
+// is copied to a temporary file in dst's directory, which is renamed
+// over dst before src is removed; as with a rename, dst is never left
+// partially written. The copy keeps src's permission bits, including
+// the setuid, setgid, and sticky bits, but not its owner or
+// timestamps. Only regular files can be copied.
+func SafeRename(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	return copyRename(src, dst)
+}
+
+// copyRename is the fallback for SafeRename when src and dst are on
+// different filesystems.
+func copyRename(src, dst string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !fi.Mode().IsRegular() {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.EXDEV}
+	}
+
+	mode := fi.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	name, err := writeTemp(filepath.Dir(dst), filepath.Base(dst), file, mode)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(name, dst); err != nil {
+		os.Remove(name)
+		return err
+	}
+
+	return os.Remove(src)
+}
@@ -0,0 +1,147 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func checkContents(t *testing.T, path, expected string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != expected {
+		t.Fatalf("fileutil: expected %s to contain '%s', have '%s'", path, expected, data)
+	}
+}
+
+func TestAtomicWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "parts.json")
+	if err := AtomicWriteFile(path, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(t, path, "original")
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("fileutil: expected mode 0600, have %o", fi.Mode().Perm())
+	}
+
+	// Simulate a crash partway through a write: the temporary file
+	// is left truncated and never renamed into place.
+	crashed, err := os.CreateTemp(dir, ".parts.json.tmp-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crashed.Write([]byte("upd"))
+	crashed.Close()
+	checkContents(t, path, "original")
+
+	if err = AtomicWriteFile(path, []byte("updated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(t, path, "updated")
+
+	// Only the original file and the crashed temporary file should
+	// remain; a successful write cleans up after itself.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		if entry.Name() != "parts.json" && entry.Name() != filepath.Base(crashed.Name()) {
+			t.Fatalf("fileutil: unexpected file %s left behind", entry.Name())
+		}
+	}
+
+	if err = AtomicWriteFile(filepath.Join(dir, "missing", "parts.json"), nil, 0600); err == nil {
+		t.Fatal("fileutil: expected a write to a missing directory to fail")
+	}
+}
+
+func TestAtomicWriteFileWithBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parts.json")
+	if err := AtomicWriteFileWithBackup(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if FileDoesExist(path + ".bak") {
+		t.Fatal("fileutil: a backup shouldn't be made when there is no old file")
+	}
+
+	for _, contents := range []string{"updated", "updated again"} {
+		if err := AtomicWriteFileWithBackup(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		checkContents(t, path, contents)
+	}
+
+	checkContents(t, path+".bak", "updated")
+}
+
+func TestSafeRename(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.WriteFile(src, []byte("contents"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SafeRename(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(t, dst, "contents")
+
+	if FileDoesExist(src) {
+		t.Fatal("fileutil: expected the source to be gone after a rename")
+	}
+
+	// The cross-device fallback can't be forced in a test, so
+	// exercise it directly.
+	if err := os.WriteFile(src, []byte("copied"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(src, 0751); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyRename(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(t, dst, "copied")
+
+	fi, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode().Perm() != 0751 {
+		t.Fatalf("fileutil: expected mode 0751 to be preserved, have %o", fi.Mode().Perm())
+	}
+
+	if FileDoesExist(src) {
+		t.Fatal("fileutil: expected the source to be removed after copying")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("fileutil: expected only dst to be left, have %d files", len(entries))
+	}
+
+	if err = copyRename(dir, filepath.Join(t.TempDir(), "dir")); err == nil {
+		t.Fatal("fileutil: expected copying a directory to fail")
+	}
+}
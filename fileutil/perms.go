@@ -0,0 +1,141 @@
+package fileutil
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// PermPolicy describes the permissions that files in a directory tree
+// are allowed to have. The zero value allows anything.
+type PermPolicy struct {
+	// MaxFilePerm is the most permissive set of permission bits
+	// that a regular file may have; a file with any other bits
+	// set violates the policy. If it is zero, file permissions
+	// aren't checked.
+	MaxFilePerm os.FileMode
+
+	// MaxDirPerm is the same as MaxFilePerm, but for directories.
+	MaxDirPerm os.FileMode
+
+	// NoSetuid rejects setuid and setgid files, other than those
+	// listed in SetuidAllowlist.
+	NoSetuid bool
+
+	// SetuidAllowlist lists the paths that may be setuid or setgid
+	// when NoSetuid is set.
+	SetuidAllowlist []string
+
+	// RequireAccess rejects regular files that the current user
+	// can't read and directories that it can't search, along with
+	// any path that couldn't be walked.
+	RequireAccess bool
+}
+
+// StrictPolicy returns a policy that rejects world-readable and
+// world-writable files and directories, and any setuid or setgid
+// files.
+func StrictPolicy() PermPolicy {
+	return PermPolicy{
+		MaxFilePerm: 0771,
+		MaxDirPerm:  0771,
+		NoSetuid:    true,
+	}
+}
+
+// PermViolation describes a file that violates a PermPolicy.
+type PermViolation struct {
+	Path string
+	Mode os.FileMode
+	Rule string
+}
+
+func (v PermViolation) String() string {
+	return fmt.Sprintf("%s (%s): %s", v.Path, v.Mode, v.Rule)
+}
+
+func (p PermPolicy) setuidAllowed(path string) bool {
+	for _, allowed := range p.SetuidAllowlist {
+		if filepath.Clean(allowed) == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p PermPolicy) check(path string, mode os.FileMode) []PermViolation {
+	var violations []PermViolation
+	violation := func(rule string, args ...interface{}) {
+		violations = append(violations, PermViolation{
+			Path: path,
+			Mode: mode,
+			Rule: fmt.Sprintf(rule, args...),
+		})
+	}
+
+	maxPerm := p.MaxFilePerm
+	if mode.IsDir() {
+		maxPerm = p.MaxDirPerm
+	}
+
+	if maxPerm != 0 {
+		if extra := mode.Perm() &^ maxPerm.Perm(); extra != 0 {
+			violation("permission bits %04o exceed the maximum of %04o", uint32(extra), uint32(maxPerm.Perm()))
+		}
+	}
+
+	if p.NoSetuid && mode&(os.ModeSetuid|os.ModeSetgid) != 0 && !p.setuidAllowed(path) {
+		violation("setuid or setgid is not allowed")
+	}
+
+	if p.RequireAccess {
+		if mode.IsRegular() && Access(path, AccessRead) != nil {
+			violation("not readable")
+		} else if mode.IsDir() && Access(path, AccessExec) != nil {
+			violation("not searchable")
+		}
+	}
+
+	return violations
+}
+
+// AuditPermissions walks the directory tree at root, returning every
+// file and directory that violates policy. Symlinks and other special
+// files are skipped. An error is returned if root itself can't be
+// walked; errors walking paths below it are reported as violations if
+// policy.RequireAccess is set, and skipped otherwise.
+func AuditPermissions(root string, policy PermPolicy) ([]PermViolation, error) {
+	var violations []PermViolation
+
+	err := filepath.Walk(root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+
+			if policy.RequireAccess {
+				violations = append(violations, PermViolation{
+					Path: path,
+					Rule: err.Error(),
+				})
+			}
+
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		mode := info.Mode()
+		if !mode.IsRegular() && !mode.IsDir() {
+			return nil
+		}
+
+		violations = append(violations, policy.check(path, mode)...)
+		return nil
+	})
+
+	return violations, err
+}
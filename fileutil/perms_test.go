@@ -0,0 +1,84 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditPermissions(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Chmod(root, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]os.FileMode{
+		"private":        0600,
+		"world-readable": 0644,
+		"world-writable": 0602,
+	}
+	for name, perm := range files {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, nil, perm); err != nil {
+			t.Fatal(err)
+		}
+
+		// The umask may have masked off some of the bits.
+		if err := os.Chmod(path, perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setuid := filepath.Join(root, "setuid")
+	if err := os.WriteFile(setuid, nil, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(setuid, 0700|os.ModeSetuid); err != nil {
+		t.Fatal(err)
+	}
+
+	violations, err := AuditPermissions(root, StrictPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]int{}
+	for _, v := range violations {
+		found[filepath.Base(v.Path)]++
+	}
+
+	for _, name := range []string{"world-readable", "world-writable", "setuid"} {
+		if found[name] != 1 {
+			t.Fatalf("fileutil: expected one violation for %s, have %d (%v)", name, found[name], violations)
+		}
+	}
+
+	if len(violations) != 3 {
+		t.Fatalf("fileutil: expected 3 violations, have %d (%v)", len(violations), violations)
+	}
+
+	policy := StrictPolicy()
+	policy.SetuidAllowlist = []string{setuid}
+	violations, err = AuditPermissions(root, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(violations) != 2 {
+		t.Fatalf("fileutil: expected 2 violations with an allowlist, have %d (%v)", len(violations), violations)
+	}
+
+	violations, err = AuditPermissions(root, PermPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(violations) != 0 {
+		t.Fatalf("fileutil: expected the zero policy to allow anything, have %v", violations)
+	}
+
+	if _, err = AuditPermissions(filepath.Join(root, "missing"), StrictPolicy()); err == nil {
+		t.Fatal("fileutil: expected auditing a missing directory to fail")
+	}
+}
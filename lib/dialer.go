@@ -0,0 +1,285 @@
+package lib
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"math/rand"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// BaselineTLSConfig returns a TLS configuration suitable as a starting
+// point for clients and servers: it requires at least TLS 1.2. If
+// verify is false, server certificates are not verified; this is
+// useful for tools that only care about reachability.
+func BaselineTLSConfig(verify bool) *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: !verify,
+	}
+}
+
+// StrictTLSConfig is like BaselineTLSConfig, but requires TLS 1.3,
+// which rules out the older cipher suites and key exchanges.
+func StrictTLSConfig(verify bool) *tls.Config {
+	cfg := BaselineTLSConfig(verify)
+	cfg.MinVersion = tls.VersionTLS13
+	return cfg
+}
+
+// StrictTLSFlag registers a -strict flag on the command line, for
+// programs that should use StrictTLSConfig rather than
+// BaselineTLSConfig when it is given. It must be called before
+// flag.Parse.
+func StrictTLSFlag() *bool {
+	return flag.Bool("strict", false, "require TLS 1.3")
+}
+
+// DialerOpts controls how connections are established by the Dial
+// functions. The zero value is usable: there is no timeout, and TLS
+// connections use BaselineTLSConfig with verification enabled.
+type DialerOpts struct {
+	// Timeout is the maximum amount of time to wait for the
+	// connection (including the TLS handshake) to complete.
+	Timeout time.Duration
+
+	// TLSConfig is used for TLS connections. It is cloned
+	// before use, so it may be shared between dials.
+	TLSConfig *tls.Config
+
+	// SOCKSProxy, if not empty, is the URL of a SOCKS5 proxy
+	// (e.g. socks5://127.0.0.1:1080) to connect through. If it is
+	// empty, a socks5 or socks5h URL in the ALL_PROXY or all_proxy
+	// environment variables is used, as curl does; hosts listed in
+	// NO_PROXY or no_proxy are then connected to directly.
+	SOCKSProxy string
+}
+
+// proxyURL returns the SOCKS5 proxy to use, or nil if connections
+// should be made directly. Proxies from the environment that aren't
+// SOCKS5 proxies are ignored.
+func (opts DialerOpts) proxyURL() (*url.URL, error) {
+	if opts.SOCKSProxy != "" {
+		return url.Parse(opts.SOCKSProxy)
+	}
+
+	for _, env := range []string{"ALL_PROXY", "all_proxy"} {
+		proxy := os.Getenv(env)
+		if proxy == "" {
+			continue
+		}
+
+		u, err := url.Parse(proxy)
+		if err != nil || (u.Scheme != "socks5" && u.Scheme != "socks5h") {
+			return nil, nil
+		}
+
+		return u, nil
+	}
+
+	return nil, nil
+}
+
+// noProxy returns the NO_PROXY or no_proxy environment variable.
+func noProxy() string {
+	if hosts := os.Getenv("NO_PROXY"); hosts != "" {
+		return hosts
+	}
+
+	return os.Getenv("no_proxy")
+}
+
+func (opts DialerOpts) tlsConfig() *tls.Config {
+	if opts.TLSConfig == nil {
+		return BaselineTLSConfig(true)
+	}
+
+	return opts.TLSConfig.Clone()
+}
+
+func (opts DialerOpts) netDialer() *net.Dialer {
+	return &net.Dialer{Timeout: opts.Timeout}
+}
+
+// dial connects to the TCP address addr, through the SOCKS5 proxy if
+// there is one.
+func (opts DialerOpts) dial(ctx context.Context, addr string) (net.Conn, error) {
+	nd := opts.netDialer()
+
+	u, err := opts.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+
+	if u == nil {
+		return nd.DialContext(ctx, "tcp", addr)
+	}
+
+	pd, err := proxy.FromURL(u, nd)
+	if err != nil {
+		return nil, err
+	}
+
+	// As with curl, NO_PROXY lists comma-separated hosts, domains,
+	// and networks that bypass a proxy from the environment, or "*"
+	// to bypass it for everything.
+	if hosts := noProxy(); opts.SOCKSProxy == "" && hosts != "" {
+		if hosts == "*" {
+			return nd.DialContext(ctx, "tcp", addr)
+		}
+
+		perHost := proxy.NewPerHost(pd, nd)
+		perHost.AddFromString(hosts)
+		pd = perHost
+	}
+
+	if cd, ok := pd.(proxy.ContextDialer); ok {
+		return cd.DialContext(ctx, "tcp", addr)
+	}
+
+	return pd.Dial("tcp", addr)
+}
+
+// DialTCP connects to the TCP address addr.
+func DialTCP(ctx context.Context, addr string, opts DialerOpts) (net.Conn, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	return opts.dial(ctx, addr)
+}
+
+// DialTCPWithRetry is like DialTCP, but if the connection fails with
+// a transient error (see IsTransient), it is retried up to
+// retries more times, waiting delay between attempts.
+func DialTCPWithRetry(ctx context.Context, addr string, opts DialerOpts, retries int, delay time.Duration) (net.Conn, error) {
+	for attempt := 0; ; attempt++ {
+		conn, err := DialTCP(ctx, addr, opts)
+		if err == nil || attempt >= retries || !IsTransient(err) {
+			return conn, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// DialTLS connects to addr and completes a TLS handshake. If the TLS
+// configuration doesn't specify a server name, it is taken from addr.
+func DialTLS(ctx context.Context, addr string, opts DialerOpts) (*tls.Conn, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	raw, err := opts.dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := opts.tlsConfig()
+	if cfg.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		cfg.ServerName = host
+	}
+
+	conn := tls.Client(raw, cfg)
+	if err = conn.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// backoff returns how long to wait before the retry following the
+// given attempt (counting from zero): baseDelay doubled for each
+// attempt, give or take 25%.
+func backoff(baseDelay time.Duration, attempt int) time.Duration {
+	delay := baseDelay
+	for i := 0; i < attempt && delay < time.Hour; i++ {
+		delay *= 2
+	}
+
+	spread := int64(delay) / 2
+	if spread <= 0 {
+		return delay
+	}
+
+	return delay - delay/4 + time.Duration(rand.Int63n(spread+1))
+}
+
+// retryableDial reports whether a failed dial is worth retrying: a
+// transient error, or a refused connection, which is usually a server
+// that hasn't started listening yet.
+func retryableDial(err error) bool {
+	return IsTransient(err) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// DialTLSWithRetry is like DialTLS, but if the connection fails with
+// a transient error or is refused, it is retried up to retries more
+// times. The first retry waits baseDelay, and each one after that
+// waits twice as long as the last, with 25% jitter so that many
+// clients don't retry in lockstep. Other errors, such as certificate
+// verification failures, aren't retried.
+func DialTLSWithRetry(ctx context.Context, addr string, opts DialerOpts, retries int, baseDelay time.Duration) (*tls.Conn, error) {
+	for attempt := 0; ; attempt++ {
+		conn, err := DialTLS(ctx, addr, opts)
+		if err == nil || attempt >= retries || !retryableDial(err) {
+			return conn, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(baseDelay, attempt)):
+		}
+	}
+}
+
+// DialMTLS connects to addr over TLS, presenting clientCert to the
+// server and verifying the server's certificate against roots. If
+// roots is nil, the system roots are used.
+func DialMTLS(ctx context.Context, addr string, clientCert tls.Certificate, roots *x509.CertPool, opts DialerOpts) (*tls.Conn, error) {
+	cfg := opts.tlsConfig()
+	cfg.Certificates = []tls.Certificate{clientCert}
+	cfg.RootCAs = roots
+	opts.TLSConfig = cfg
+
+	return DialTLS(ctx, addr, opts)
+}
+
+// ListenMTLS listens for TLS connections on addr, presenting
+// serverCert to clients. Clients must present a certificate that
+// verifies against clientRoots.
+func ListenMTLS(ctx context.Context, addr string, serverCert tls.Certificate, clientRoots *x509.CertPool) (net.Listener, error) {
+	cfg := BaselineTLSConfig(true)
+	cfg.Certificates = []tls.Certificate{serverCert}
+	cfg.ClientCAs = clientRoots
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+
+	var lc net.ListenConfig
+	l, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return tls.NewListener(l, cfg), nil
+}
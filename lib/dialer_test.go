@@ -0,0 +1,430 @@
+package lib
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
+
+func echo(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+			io.Copy(conn, conn)
+		}()
+	}
+}
+
+func TestMTLS(t *testing.T) {
+	ctx := context.Background()
+	ca := newTestCA(t)
+	serverCert := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	clientCert := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+
+	l, err := ListenMTLS(ctx, "127.0.0.1:0", serverCert, ca.pool())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go echo(l)
+
+	opts := DialerOpts{Timeout: 5 * time.Second}
+	conn, err := DialMTLS(ctx, l.Addr().String(), clientCert, ca.pool(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	msg := []byte("hello, world")
+	if _, err = conn.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, len(msg))
+	if _, err = io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buf) != string(msg) {
+		t.Fatalf("lib: expected echo of '%s', have '%s'", msg, buf)
+	}
+
+	// Without a client certificate, the server should refuse the
+	// connection; in TLS 1.3, this isn't seen until the first read.
+	conn, err = DialTLS(ctx, l.Addr().String(), DialerOpts{
+		Timeout:   5 * time.Second,
+		TLSConfig: &tls.Config{RootCAs: ca.pool()},
+	})
+	if err == nil {
+		defer conn.Close()
+		conn.Write(msg)
+		_, err = io.ReadFull(conn, buf)
+	}
+
+	if err == nil {
+		t.Fatal("lib: expected the server to require a client certificate")
+	}
+}
+
+func TestDialTCPWithRetry(t *testing.T) {
+	ctx := context.Background()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go echo(l)
+
+	conn, err := DialTCPWithRetry(ctx, l.Addr().String(), DialerOpts{}, 3, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// A refused connection isn't transient, so it shouldn't be
+	// retried.
+	addr := l.Addr().String()
+	l.Close()
+
+	start := time.Now()
+	if _, err = DialTCPWithRetry(ctx, addr, DialerOpts{}, 3, time.Second); err == nil {
+		t.Fatal("lib: expected the connection to be refused")
+	}
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Fatalf("lib: expected a refused connection not to be retried, but it took %s", elapsed)
+	}
+}
+
+// socks5Stub is a minimal SOCKS5 proxy that supports unauthenticated
+// CONNECT requests to IPv4 addresses, and counts the connections it
+// proxies.
+type socks5Stub struct {
+	l     net.Listener
+	conns chan struct{}
+}
+
+func newSOCKS5Stub(t *testing.T) *socks5Stub {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &socks5Stub{l: l, conns: make(chan struct{}, 16)}
+	go stub.serve()
+	return stub
+}
+
+func (stub *socks5Stub) URL() string {
+	return "socks5://" + stub.l.Addr().String()
+}
+
+func (stub *socks5Stub) serve() {
+	for {
+		conn, err := stub.l.Accept()
+		if err != nil {
+			return
+		}
+
+		go stub.handle(conn)
+	}
+}
+
+func (stub *socks5Stub) handle(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting: version, number of methods, methods.
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(conn, hdr); err != nil {
+		return
+	}
+
+	if _, err := io.ReadFull(conn, make([]byte, hdr[1])); err != nil {
+		return
+	}
+
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	// Request: version, command, reserved, address type, IPv4
+	// address, and port.
+	req := make([]byte, 10)
+	if _, err := io.ReadFull(conn, req); err != nil || req[1] != 1 || req[3] != 1 {
+		return
+	}
+
+	addr := &net.TCPAddr{IP: net.IP(req[4:8]), Port: int(req[8])<<8 | int(req[9])}
+	target, err := net.DialTCP("tcp", nil, addr)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+
+	if _, err = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+	stub.conns <- struct{}{}
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+func checkEcho(t *testing.T, conn net.Conn) {
+	msg := []byte("hello, world")
+	if _, err := conn.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buf) != string(msg) {
+		t.Fatalf("lib: expected echo of '%s', have '%s'", msg, buf)
+	}
+}
+
+func TestDialSOCKSProxy(t *testing.T) {
+	ctx := context.Background()
+	stub := newSOCKS5Stub(t)
+	defer stub.l.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go echo(l)
+
+	opts := DialerOpts{Timeout: 5 * time.Second, SOCKSProxy: stub.URL()}
+	conn, err := DialTCP(ctx, l.Addr().String(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	checkEcho(t, conn)
+
+	select {
+	case <-stub.conns:
+	default:
+		t.Fatal("lib: expected the connection to go through the proxy")
+	}
+
+	// The proxy may also be given in the environment.
+	t.Setenv("ALL_PROXY", stub.URL())
+	conn, err = DialTCP(ctx, l.Addr().String(), DialerOpts{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	checkEcho(t, conn)
+
+	select {
+	case <-stub.conns:
+	default:
+		t.Fatal("lib: expected ALL_PROXY to be used")
+	}
+
+	// Hosts in NO_PROXY are connected to directly.
+	for _, hosts := range []string{"127.0.0.1", "example.net,127.0.0.0/8", "*"} {
+		t.Setenv("NO_PROXY", hosts)
+		conn, err = DialTCP(ctx, l.Addr().String(), DialerOpts{Timeout: 5 * time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		checkEcho(t, conn)
+
+		select {
+		case <-stub.conns:
+			t.Fatalf("lib: expected NO_PROXY=%s to bypass the proxy", hosts)
+		default:
+		}
+	}
+
+	t.Setenv("NO_PROXY", "example.net")
+	conn, err = DialTCP(ctx, l.Addr().String(), DialerOpts{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	checkEcho(t, conn)
+
+	select {
+	case <-stub.conns:
+	default:
+		t.Fatal("lib: expected a host not in NO_PROXY to use the proxy")
+	}
+}
+
+func TestDialTLSSOCKSProxy(t *testing.T) {
+	ctx := context.Background()
+	stub := newSOCKS5Stub(t)
+	defer stub.l.Close()
+
+	ca := newTestCA(t)
+	cfg := BaselineTLSConfig(true)
+	cfg.Certificates = []tls.Certificate{ca.issue(t, 2, x509.ExtKeyUsageServerAuth)}
+	l, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go echo(l)
+
+	conn, err := DialTLS(ctx, l.Addr().String(), DialerOpts{
+		Timeout:    5 * time.Second,
+		TLSConfig:  &tls.Config{RootCAs: ca.pool()},
+		SOCKSProxy: stub.URL(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	checkEcho(t, conn)
+
+	select {
+	case <-stub.conns:
+	default:
+		t.Fatal("lib: expected the connection to go through the proxy")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := 0; attempt < 4; attempt++ {
+		expected := base << attempt
+		for i := 0; i < 100; i++ {
+			delay := backoff(base, attempt)
+			if delay < expected*3/4 || delay > expected*5/4 {
+				t.Fatalf("lib: attempt %d: delay %s is outside %s ± 25%%", attempt, delay, expected)
+			}
+		}
+	}
+}
+
+func TestDialTLSWithRetry(t *testing.T) {
+	ctx := context.Background()
+	ca := newTestCA(t)
+	serverCert := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	go echo(l)
+
+	opts := DialerOpts{TLSConfig: &tls.Config{RootCAs: ca.pool()}}
+	conn, err := DialTLSWithRetry(ctx, addr, opts, 3, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// A certificate verification failure is permanent, so it
+	// shouldn't be retried.
+	start := time.Now()
+	if _, err = DialTLSWithRetry(ctx, addr, DialerOpts{}, 3, time.Second); err == nil {
+		t.Fatal("lib: expected certificate verification to fail")
+	}
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Fatalf("lib: expected a verification failure not to be retried, but it took %s", elapsed)
+	}
+
+	// A refused connection is retried, backing off each time.
+	l.Close()
+	start = time.Now()
+	if _, err = DialTLSWithRetry(ctx, addr, opts, 2, 20*time.Millisecond); err == nil {
+		t.Fatal("lib: expected the connection to be refused")
+	}
+
+	minimum := 20*time.Millisecond*3/4 + 40*time.Millisecond*3/4
+	if elapsed := time.Since(start); elapsed < minimum {
+		t.Fatalf("lib: expected two retries to take at least %s, took %s", minimum, elapsed)
+	}
+}
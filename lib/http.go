@@ -0,0 +1,58 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+type rateLimitedTransport struct {
+	limiter *rate.Limiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// NewRateLimitedHTTPClient returns an HTTP client that sends at most
+// rps requests per second across all of its connections. Requests
+// over the limit wait their turn, until the request's context is
+// cancelled. Connections are made using opts, through the same
+// proxies as NewHTTPClient.
+func NewRateLimitedHTTPClient(rps float64, opts DialerOpts) (*http.Client, error) {
+	if rps <= 0 {
+		return nil, errors.New("lib: requests per second must be positive")
+	}
+
+	return &http.Client{
+		Transport: &rateLimitedTransport{
+			limiter: rate.NewLimiter(rate.Limit(rps), 1),
+			next:    NewHTTPClient(opts).Transport,
+		},
+	}, nil
+}
+
+// NewHTTPClient returns an HTTP client that makes its connections
+// using opts. HTTP proxies are taken from the environment, as with
+// http.DefaultTransport; otherwise, connections go through the SOCKS5
+// proxy given in opts or the environment, if there is one.
+func NewHTTPClient(opts DialerOpts) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return DialTCP(ctx, addr, opts)
+	}
+	transport.TLSClientConfig = opts.tlsConfig()
+	if opts.Timeout > 0 {
+		transport.TLSHandshakeTimeout = opts.Timeout
+	}
+
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	const rps = 20
+	const requests = 5
+
+	client, err := NewRateLimitedHTTPClient(rps, DialerOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				errs <- err
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	minimum := time.Duration(float64(requests-1) / rps * float64(time.Second))
+	if elapsed := time.Since(start); elapsed < minimum {
+		t.Fatalf("lib: %d requests at %d rps took %s, expected at least %s", requests, rps, elapsed, minimum)
+	}
+
+	if _, err = NewRateLimitedHTTPClient(0, DialerOpts{}); err == nil {
+		t.Fatal("lib: expected an error with a zero rate")
+	}
+}
+
+func TestRateLimitedHTTPClientSOCKSProxy(t *testing.T) {
+	stub := newSOCKS5Stub(t)
+	defer stub.l.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := NewRateLimitedHTTPClient(10, DialerOpts{Timeout: 5 * time.Second, SOCKSProxy: stub.URL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	select {
+	case <-stub.conns:
+	default:
+		t.Fatal("lib: expected the request to go through the proxy")
+	}
+}
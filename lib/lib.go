@@ -2,9 +2,11 @@
 package lib
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -74,6 +76,44 @@ func Itoa(i int, wid int) string {
 	return string(b[bp:])
 }
 
+// HexEncodeMode selects how HexEncode formats its output.
+type HexEncodeMode uint8
+
+const (
+	// HexEncodeLower is plain lowercase hex, e.g. "3aabd1".
+	HexEncodeLower HexEncodeMode = iota
+	// HexEncodeUpper is plain uppercase hex, e.g. "3AABD1".
+	HexEncodeUpper
+	// HexEncodeLowerColon is lowercase hex with colons between
+	// bytes, e.g. "3a:ab:d1".
+	HexEncodeLowerColon
+	// HexEncodeUpperColon is uppercase hex with colons between
+	// bytes, e.g. "3A:AB:D1", as OpenSSL prints key identifiers.
+	HexEncodeUpperColon
+)
+
+// HexEncode returns the hex encoding of b in the given mode.
+func HexEncode(b []byte, mode HexEncodeMode) string {
+	s := hex.EncodeToString(b)
+	if mode == HexEncodeUpper || mode == HexEncodeUpperColon {
+		s = strings.ToUpper(s)
+	}
+
+	if mode != HexEncodeLowerColon && mode != HexEncodeUpperColon {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i += 2 {
+		if i > 0 {
+			sb.WriteByte(':')
+		}
+		sb.WriteString(s[i : i+2])
+	}
+
+	return sb.String()
+}
+
 var (
 	dayDuration  = 24 * time.Hour
 	yearDuration = (365 * dayDuration) + (6 * time.Hour)
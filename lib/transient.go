@@ -0,0 +1,24 @@
+package lib
+
+import (
+	"errors"
+	"net"
+)
+
+// IsTransient reports whether err is likely to be transient, such that
+// the operation that caused it is worth retrying: either an error with
+// a Transient method that returns true, or a net.Error that is a
+// timeout or is marked as temporary.
+func IsTransient(err error) bool {
+	var terr interface{ Transient() bool }
+	if errors.As(err, &terr) && terr.Transient() {
+		return true
+	}
+
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		return nerr.Timeout() || nerr.Temporary()
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+type transientError bool
+
+func (err transientError) Error() string   { return "transient test error" }
+func (err transientError) Transient() bool { return bool(err) }
+
+func TestIsTransient(t *testing.T) {
+	err := errors.New("test error")
+	transient := []error{
+		transientError(true),
+		fmt.Errorf("dialing: %w", transientError(true)),
+		&net.DNSError{Err: "timeout", IsTimeout: true},
+		fmt.Errorf("fetching CRL: %w", context.DeadlineExceeded),
+	}
+
+	for _, err := range transient {
+		if !IsTransient(err) {
+			t.Fatalf("lib: expected '%v' to be transient", err)
+		}
+	}
+
+	permanent := []error{
+		err,
+		transientError(false),
+		&net.DNSError{Err: "no such host", IsNotFound: true},
+	}
+
+	for _, err := range permanent {
+		if IsTransient(err) {
+			t.Fatalf("lib: expected '%v' not to be transient", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// start is used to provide a monotonic timestamp for audit events,
+// which can't be affected by changes to the wall clock.
+var start = time.Now()
+
+func (log *logger) setAuditWriter(w io.Writer) {
+	if w == nil {
+		w = os.Stderr
+	}
+
+	log.auditLock.Lock()
+	log.audit = w
+	log.auditLock.Unlock()
+}
+
+// auditFields converts a list of alternating keys and values to a
+// map. A trailing key without a value is recorded with a nil value.
+func auditFields(fields []any) map[string]any {
+	m := make(map[string]any, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+
+		var value any
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+
+		// Most errors marshal to an empty object, which isn't
+		// useful in an audit log.
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+
+		m[key] = value
+	}
+
+	return m
+}
+
+func (log *logger) auditEvent(msg string, fields ...any) {
+	now := time.Now()
+	event := struct {
+		Time      string         `json:"time"`
+		Monotonic int64          `json:"monotonic"`
+		Message   string         `json:"msg"`
+		Fields    map[string]any `json:"fields,omitempty"`
+	}{
+		Time:      now.Format(time.RFC3339Nano),
+		Monotonic: int64(now.Sub(start)),
+		Message:   msg,
+		Fields:    auditFields(fields),
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		line, _ = json.Marshal(struct {
+			Time      string `json:"time"`
+			Monotonic int64  `json:"monotonic"`
+			Message   string `json:"msg"`
+			Error     string `json:"error"`
+		}{event.Time, event.Monotonic, msg, err.Error()})
+	}
+	line = append(line, '\n')
+
+	log.auditLock.Lock()
+	defer log.auditLock.Unlock()
+	log.audit.Write(line)
+}
+
+// Audit writes an audit event to the audit writer as a single line of
+// JSON. Audit events are always written, regardless of the log
+// level. The fields are alternating keys and values, e.g.
+//
+//	log.Audit("private key loaded", "path", keyFile, "type", "ECDSA")
+func Audit(msg string, fields ...any) {
+	log.auditEvent(msg, fields...)
+}
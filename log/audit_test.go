@@ -0,0 +1,84 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAuditFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []any
+		want   map[string]any
+	}{
+		{"empty", nil, map[string]any{}},
+		{"pairs", []any{"path", "key.pem", "bits", 256}, map[string]any{"path": "key.pem", "bits": 256}},
+		{"trailing key", []any{"path", "key.pem", "type"}, map[string]any{"path": "key.pem", "type": nil}},
+		{"error value", []any{"err", errors.New("permission denied")}, map[string]any{"err": "permission denied"}},
+		{"non-string key", []any{1, true}, map[string]any{"1": true}},
+	}
+
+	for _, test := range tests {
+		if have := auditFields(test.fields); !reflect.DeepEqual(have, test.want) {
+			t.Fatalf("log: %s: expected fields %v, have %v", test.name, test.want, have)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log.setAuditWriter(buf)
+	defer log.setAuditWriter(nil)
+
+	tests := []struct {
+		msg    string
+		fields []any
+		want   map[string]any
+		err    bool
+	}{
+		{"private key loaded", []any{"path", "key.pem", "bits", 256}, map[string]any{"path": "key.pem", "bits": 256.0}, false},
+		{"no fields", nil, nil, false},
+		{"unmarshalable", []any{"ch", make(chan int)}, nil, true},
+	}
+
+	for _, test := range tests {
+		buf.Reset()
+		Audit(test.msg, test.fields...)
+
+		line := buf.Bytes()
+		if len(line) == 0 || line[len(line)-1] != '\n' || bytes.Count(line, []byte("\n")) != 1 {
+			t.Fatalf("log: %s: expected a single line, have %q", test.msg, line)
+		}
+
+		var event struct {
+			Time      string         `json:"time"`
+			Monotonic int64          `json:"monotonic"`
+			Message   string         `json:"msg"`
+			Fields    map[string]any `json:"fields"`
+			Error     string         `json:"error"`
+		}
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatalf("log: %s: invalid audit event: %v", test.msg, err)
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, event.Time); err != nil {
+			t.Fatalf("log: %s: invalid timestamp: %v", test.msg, err)
+		}
+
+		if event.Message != test.msg || event.Monotonic <= 0 {
+			t.Fatalf("log: %s: unexpected audit event %s", test.msg, line)
+		}
+
+		if !reflect.DeepEqual(event.Fields, test.want) {
+			t.Fatalf("log: %s: expected fields %v, have %v", test.msg, test.want, event.Fields)
+		}
+
+		if test.err != (event.Error != "") {
+			t.Fatalf("log: %s: unexpected marshaling error '%s'", test.msg, event.Error)
+		}
+	}
+}
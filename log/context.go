@@ -0,0 +1,23 @@
+package log
+
+import "context"
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx that carries e, so that functions
+// handling a request or job can log with its fields without passing
+// the Entry around explicitly.
+func WithLogger(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// FromContext returns the Entry stored in ctx by WithLogger. If there
+// isn't one, it returns an Entry with no fields, which logs the same
+// way as the package-level functions.
+func FromContext(ctx context.Context) *Entry {
+	if e, ok := ctx.Value(contextKey{}).(*Entry); ok && e != nil {
+		return e
+	}
+
+	return &Entry{}
+}
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	e := WithFields(map[string]any{"request": 42})
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		want   *Entry
+		fields string
+	}{
+		{"stored", WithLogger(context.Background(), e), e, `request="42" `},
+		{"missing", context.Background(), nil, ""},
+		{"nil entry", WithLogger(context.Background(), nil), nil, ""},
+	}
+
+	for _, test := range tests {
+		have := FromContext(test.ctx)
+		if have == nil {
+			t.Fatalf("log: %s: expected an entry", test.name)
+		}
+
+		if test.want != nil && have != test.want {
+			t.Fatalf("log: %s: expected the stored entry", test.name)
+		}
+
+		if fields := have.format(false); fields != test.fields {
+			t.Fatalf("log: %s: expected fields %q, have %q", test.name, test.fields, fields)
+		}
+	}
+}
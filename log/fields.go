@@ -0,0 +1,113 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	gsyslog "github.com/hashicorp/go-syslog"
+	"golang.org/x/term"
+)
+
+const (
+	colorKey   = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+// An Entry is a set of fields that are prepended, as key=value pairs,
+// to each message logged through it. Entries are created with
+// WithFields.
+type Entry struct {
+	keys   []string
+	values []string
+}
+
+// WithFields returns an Entry that logs with the given fields. The
+// fields are written in order of their keys, e.g.
+//
+//	log.WithFields(map[string]any{"path": keyFile, "type": "ECDSA"}).Infof("loaded private key")
+func WithFields(fields map[string]any) *Entry {
+	e := &Entry{}
+	for key := range fields {
+		e.keys = append(e.keys, key)
+	}
+	sort.Strings(e.keys)
+
+	for _, key := range e.keys {
+		e.values = append(e.values, fmt.Sprint(fields[key]))
+	}
+
+	return e
+}
+
+// format returns the fields as a string ending in a space. If color
+// is true, the keys are highlighted for display on a terminal;
+// otherwise, the values are quoted so that the output can be parsed.
+func (e *Entry) format(color bool) string {
+	var sb strings.Builder
+	for i, key := range e.keys {
+		if color {
+			fmt.Fprintf(&sb, "%s%s%s=%s ", colorKey, key, colorReset, e.values[i])
+		} else {
+			fmt.Fprintf(&sb, "%s=%s ", key, strconv.Quote(e.values[i]))
+		}
+	}
+
+	return sb.String()
+}
+
+func (e *Entry) printf(p gsyslog.Priority, format string, args ...interface{}) {
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	msg := fmt.Sprintf(format, args...)
+
+	if p <= log.p && log.writeConsole {
+		color := term.IsTerminal(int(os.Stdout.Fd()))
+		fmt.Printf("%s [%s] %s%s", prioritiev[p], timestamp(), e.format(color), msg)
+	}
+
+	if log.l != nil {
+		log.l.WriteLevel(p, []byte(e.format(false)+msg))
+	}
+}
+
+func (e *Entry) Debugf(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_DEBUG, format, args...)
+}
+
+func (e *Entry) Infof(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_INFO, format, args...)
+}
+
+func (e *Entry) Noticef(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_NOTICE, format, args...)
+}
+
+func (e *Entry) Warningf(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_WARNING, format, args...)
+}
+
+func (e *Entry) Errf(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_ERR, format, args...)
+}
+
+func (e *Entry) Critf(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_CRIT, format, args...)
+}
+
+func (e *Entry) Alertf(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_ALERT, format, args...)
+}
+
+func (e *Entry) Emergf(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_EMERG, format, args...)
+	os.Exit(1)
+}
+
+func (e *Entry) Fatalf(format string, args ...interface{}) {
+	e.printf(gsyslog.LOG_ERR, format, args...)
+	os.Exit(1)
+}
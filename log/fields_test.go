@@ -0,0 +1,90 @@
+package log
+
+import (
+	"testing"
+
+	gsyslog "github.com/hashicorp/go-syslog"
+)
+
+// testSyslogger records the messages written to it.
+type testSyslogger struct {
+	priorities []gsyslog.Priority
+	messages   []string
+}
+
+func (s *testSyslogger) WriteLevel(p gsyslog.Priority, msg []byte) error {
+	s.priorities = append(s.priorities, p)
+	s.messages = append(s.messages, string(msg))
+	return nil
+}
+
+func (s *testSyslogger) Write(msg []byte) (int, error) {
+	return len(msg), s.WriteLevel(gsyslog.LOG_INFO, msg)
+}
+
+func (s *testSyslogger) Close() error {
+	return nil
+}
+
+func TestEntryFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]any
+		color  bool
+		want   string
+	}{
+		{"empty", nil, false, ""},
+		{"single", map[string]any{"path": "/tmp/key.pem"}, false, `path="/tmp/key.pem" `},
+		{"sorted", map[string]any{"type": "ECDSA", "bits": 256}, false, `bits="256" type="ECDSA" `},
+		{"quoted", map[string]any{"msg": `say "hi"`}, false, `msg="say \"hi\"" `},
+		{"color", map[string]any{"type": "ECDSA", "bits": 256}, true,
+			colorKey + "bits" + colorReset + "=256 " + colorKey + "type" + colorReset + "=ECDSA "},
+		{"color unquoted", map[string]any{"msg": "two words"}, true,
+			colorKey + "msg" + colorReset + "=two words "},
+	}
+
+	for _, test := range tests {
+		if have := WithFields(test.fields).format(test.color); have != test.want {
+			t.Fatalf("log: %s: expected fields %q, have %q", test.name, test.want, have)
+		}
+	}
+}
+
+func TestEntryLog(t *testing.T) {
+	oldL, oldP, oldConsole := log.l, log.p, log.writeConsole
+	defer func() { log.l, log.p, log.writeConsole = oldL, oldP, oldConsole }()
+
+	e := WithFields(map[string]any{"path": "key.pem"})
+	tests := []struct {
+		name     string
+		logf     func(string, ...interface{})
+		priority gsyslog.Priority
+	}{
+		{"Debugf", e.Debugf, gsyslog.LOG_DEBUG},
+		{"Infof", e.Infof, gsyslog.LOG_INFO},
+		{"Noticef", e.Noticef, gsyslog.LOG_NOTICE},
+		{"Warningf", e.Warningf, gsyslog.LOG_WARNING},
+		{"Errf", e.Errf, gsyslog.LOG_ERR},
+		{"Critf", e.Critf, gsyslog.LOG_CRIT},
+		{"Alertf", e.Alertf, gsyslog.LOG_ALERT},
+	}
+
+	for _, test := range tests {
+		s := &testSyslogger{}
+		log.l, log.p, log.writeConsole = s, gsyslog.LOG_DEBUG, false
+
+		test.logf("loaded %s", "private key")
+		if len(s.messages) != 1 {
+			t.Fatalf("log: %s: expected one message, have %d", test.name, len(s.messages))
+		}
+
+		if s.priorities[0] != test.priority {
+			t.Fatalf("log: %s: expected priority %s, have %s", test.name,
+				prioritiev[test.priority], prioritiev[s.priorities[0]])
+		}
+
+		if want := "path=\"key.pem\" loaded private key\n"; s.messages[0] != want {
+			t.Fatalf("log: %s: expected message %q, have %q", test.name, want, s.messages[0])
+		}
+	}
+}
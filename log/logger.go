@@ -3,8 +3,10 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
@@ -15,6 +17,9 @@ type logger struct {
 	l            gsyslog.Syslogger
 	p            gsyslog.Priority
 	writeConsole bool
+
+	auditLock sync.Mutex
+	audit     io.Writer
 }
 
 func (log *logger) printf(p gsyslog.Priority, format string, args ...interface{}) {
@@ -70,7 +75,7 @@ func (log *logger) adjustPriority(level string) error {
 	return nil
 }
 
-var log = &logger{p: gsyslog.LOG_WARNING}
+var log = &logger{p: gsyslog.LOG_WARNING, audit: os.Stderr}
 
 var priorities = map[string]gsyslog.Priority{
 	"EMERG":   gsyslog.LOG_EMERG,
@@ -104,6 +109,10 @@ type Options struct {
 	Facility     string
 	WriteSyslog  bool
 	WriteConsole bool
+
+	// AuditWriter receives audit events; if it is nil, they are
+	// written to standard error.
+	AuditWriter io.Writer
 }
 
 // DefaultOptions returns a sane set of defaults for syslog, using the program
@@ -148,6 +157,7 @@ func Setup(opts *Options) error {
 
 	log.p = priority
 	log.writeConsole = opts.WriteConsole
+	log.setAuditWriter(opts.AuditWriter)
 
 	if opts.WriteSyslog {
 		var err error